@@ -102,6 +102,11 @@ func main() {
 	// 创建路由引擎
 	r := gin.Default()
 
+	// 客户端 IP 用于按 IP 限流，只信任配置的反向代理转发的 X-Forwarded-For，避免伪造
+	if err := r.SetTrustedProxies(config.GlobalConfig.Server.TrustedProxies); err != nil {
+		log.Fatalf("trusted_proxies 配置无效: %v", err)
+	}
+
 	// 自定义模板函数 - 注意要放在加载模板前面
 	r.SetFuncMap(template.FuncMap{
 		"UnixToTime": UnixToTime,
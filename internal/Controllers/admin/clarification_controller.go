@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+	"dachuang/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// permContestJury 比赛裁判权限，可回复答疑与发布公告（管理员同样可以）
+const permContestJury = "contest_jury"
+
+// clarificationMaxLength 提问与回复的最大字符数
+const clarificationMaxLength = 2000
+
+// cannedAnswers 预设回复，回复时通过 canned 指定
+var cannedAnswers = map[string]string{
+	"no_comment":     "无可奉告",
+	"read_statement": "请仔细阅读题面",
+}
+
+// ClarificationController 比赛答疑：提问、回复、公告与通知推送
+type ClarificationController struct {
+	db       *gorm.DB
+	contests *ContestController
+	events   *services.EventHub
+}
+
+// NewClarificationController 创建答疑控制器，通知通过评测事件中心推送
+func NewClarificationController(db *gorm.DB, contests *ContestController) *ClarificationController {
+	return &ClarificationController{db: db, contests: contests, events: contests.submissions.judgeService.Events}
+}
+
+// ClarificationRequest 提问或发布公告请求
+type ClarificationRequest struct {
+	Label   string `json:"label"` // 题号，为空表示关于比赛整体
+	Content string `json:"content" binding:"required"`
+}
+
+// ClarificationAnswerRequest 回复请求，answer 与 canned 二选一
+type ClarificationAnswerRequest struct {
+	Answer    string `json:"answer"`
+	Canned    string `json:"canned"`    // no_comment / read_statement
+	Broadcast bool   `json:"broadcast"` // 是否公开给全体选手
+}
+
+// isJury 是否为比赛裁判
+func isJury(op string) bool {
+	return util.UserInstance.HasPermission(op, "admin") || util.UserInstance.HasPermission(op, permContestJury)
+}
+
+// requireJury 校验操作人为比赛裁判
+func (cl *ClarificationController) requireJury(c *gin.Context) (string, bool) {
+	op, ok := requireOperatorUUID(cl.db, c)
+	if !ok {
+		return "", false
+	}
+	if !isJury(op) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return "", false
+	}
+	return op, true
+}
+
+// checkText 校验提问或回复内容
+func checkText(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return "", "内容不能为空"
+	case utf8.RuneCountInString(text) > clarificationMaxLength:
+		return "", "内容过长"
+	}
+	return text, ""
+}
+
+// checkLabel 校验题号属于该比赛，空题号表示比赛整体
+func (cl *ClarificationController) checkLabel(contestID uint, label string) (string, bool) {
+	label = strings.ToUpper(strings.TrimSpace(label))
+	if label == "" {
+		return "", true
+	}
+	var count int64
+	cl.db.Model(&models.ContestProblem{}).Where("contest_id = ? AND label = ?", contestID, label).Count(&count)
+	return label, count > 0
+}
+
+// record 写入答疑审计记录
+func (cl *ClarificationController) record(tx *gorm.DB, clar *models.Clarification, actor, action, detail string) error {
+	return tx.Create(&models.ClarificationLog{
+		ClarificationID: clar.ID,
+		ContestID:       clar.ContestID,
+		Actor:           actor,
+		Action:          action,
+		Detail:          detail,
+	}).Error
+}
+
+// notify 推送答疑通知：提问只通知裁判，私下回复通知提问者，公开回复与公告通知全体
+func (cl *ClarificationController) notify(clar *models.Clarification, action string) {
+	ev := services.JudgeEvent{Type: services.EventClarification, ContestID: clar.ContestID, ClarificationID: clar.ID}
+	switch action {
+	case models.ClarificationAsk:
+		ev.Status = "asked"
+	case models.ClarificationAnswer:
+		ev.Status = "answered"
+		ev.UserID = clar.UserID
+	default:
+		ev.Status = "broadcast"
+	}
+	cl.events.Publish(ev)
+}
+
+// clarificationView 选手看到的答疑：他人的公开提问不显示提问者与回复裁判
+func clarificationView(clar models.Clarification, op string, jury bool) models.Clarification {
+	if !jury && clar.UserID != op {
+		clar.UserID = ""
+	}
+	if !jury {
+		clar.AnsweredBy = ""
+	}
+	return clar
+}
+
+// Index 答疑列表：裁判看到全部，选手看到自己的提问与公开的答疑
+// 轮询时传 since（RFC3339，上次返回的 server_time），只返回之后新增或更新的答疑
+func (cl *ClarificationController) Index(c *gin.Context) {
+	contest, ok := cl.contests.findContest(c)
+	if !ok {
+		return
+	}
+	op := operatorUUIDFromRequest(c)
+	jury := isJury(op)
+	now := time.Now()
+
+	query := cl.db.Where("contest_id = ?", contest.ID)
+	if !jury {
+		query = query.Where("public = ? OR (user_id = ? AND user_id <> '')", true, op)
+	}
+	if since := strings.TrimSpace(c.Query("since")); since != "" {
+		t, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since 格式错误，需为 RFC3339 时间"})
+			return
+		}
+		query = query.Where("updated_at > ?", t)
+	}
+	if c.Query("unanswered") == "1" && jury {
+		query = query.Where("answered_at IS NULL")
+	}
+
+	var clars []models.Clarification
+	if err := query.Order("updated_at DESC").Find(&clars).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询答疑失败"})
+		return
+	}
+	views := make([]models.Clarification, 0, len(clars))
+	for _, clar := range clars {
+		views = append(views, clarificationView(clar, op, jury))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": views, "server_time": now})
+}
+
+// Ask 选手提问（比赛进行中，需已报名）
+func (cl *ClarificationController) Ask(c *gin.Context) {
+	op, ok := requireOperatorUUID(cl.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cl.contests.findContest(c)
+	if !ok {
+		return
+	}
+	var req ClarificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if contest.State(time.Now()) != models.ContestRunning {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能在比赛进行中提问"})
+		return
+	}
+	if !models.IsRegistered(cl.db, contest.ID, op) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "未报名该比赛"})
+		return
+	}
+	content, msg := checkText(req.Content)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	label, ok := cl.checkLabel(contest.ID, req.Label)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目不属于该比赛"})
+		return
+	}
+
+	clar := models.Clarification{ContestID: contest.ID, Label: label, UserID: op, Content: content}
+	err := cl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&clar).Error; err != nil {
+			return err
+		}
+		return cl.record(tx, &clar, op, models.ClarificationAsk, content)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提问失败"})
+		return
+	}
+	cl.notify(&clar, models.ClarificationAsk)
+	c.JSON(http.StatusCreated, gin.H{"data": clar, "msg": "问题已提交"})
+}
+
+// Announce 裁判发布公告，对全体选手可见
+func (cl *ClarificationController) Announce(c *gin.Context) {
+	op, ok := cl.requireJury(c)
+	if !ok {
+		return
+	}
+	contest, ok := cl.contests.findContest(c)
+	if !ok {
+		return
+	}
+	var req ClarificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	text, msg := checkText(req.Content)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	label, ok := cl.checkLabel(contest.ID, req.Label)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目不属于该比赛"})
+		return
+	}
+
+	now := time.Now()
+	clar := models.Clarification{
+		ContestID:  contest.ID,
+		Label:      label,
+		UserID:     op,
+		Answer:     text,
+		AnsweredBy: op,
+		AnsweredAt: &now,
+		Public:     true,
+	}
+	err := cl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&clar).Error; err != nil {
+			return err
+		}
+		return cl.record(tx, &clar, op, models.ClarificationAnnounce, text)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发布公告失败"})
+		return
+	}
+	cl.notify(&clar, models.ClarificationAnnounce)
+	c.JSON(http.StatusCreated, gin.H{"data": clar, "msg": "公告已发布"})
+}
+
+// Answer 裁判回复提问，可使用预设回复；已回复的答疑可以修改回复或改为公开，公开后不能改回私下
+func (cl *ClarificationController) Answer(c *gin.Context) {
+	op, ok := cl.requireJury(c)
+	if !ok {
+		return
+	}
+	contest, ok := cl.contests.findContest(c)
+	if !ok {
+		return
+	}
+	var clar models.Clarification
+	if err := cl.db.Where("id = ? AND contest_id = ?", c.Param("cid"), contest.ID).First(&clar).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "答疑不存在"})
+		return
+	}
+	var req ClarificationAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	answer := req.Answer
+	if req.Canned != "" {
+		canned, ok := cannedAnswers[req.Canned]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "canned 仅支持 no_comment/read_statement"})
+			return
+		}
+		answer = canned
+	}
+	answer, msg := checkText(answer)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if clar.Public && !req.Broadcast {
+		c.JSON(http.StatusConflict, gin.H{"error": "已公开的答疑不能改为私下回复"})
+		return
+	}
+
+	action := models.ClarificationAnswer
+	if req.Broadcast {
+		action = models.ClarificationBroadcast
+	}
+	now := time.Now()
+	clar.Answer = answer
+	clar.AnsweredBy = op
+	clar.AnsweredAt = &now
+	clar.Public = req.Broadcast
+	err := cl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&clar).Error; err != nil {
+			return err
+		}
+		return cl.record(tx, &clar, op, action, answer)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "回复失败"})
+		return
+	}
+	cl.notify(&clar, action)
+	c.JSON(http.StatusOK, gin.H{"data": clar, "msg": "已回复"})
+}
+
+// Audit 答疑操作记录（管理员），可按 clarification_id 过滤
+func (cl *ClarificationController) Audit(c *gin.Context) {
+	if _, ok := requireAdmin(cl.db, c); !ok {
+		return
+	}
+	query := cl.db.Where("contest_id = ?", c.Param("id"))
+	if id := strings.TrimSpace(c.Query("clarification_id")); id != "" {
+		cid, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "clarification_id 无效"})
+			return
+		}
+		query = query.Where("clarification_id = ?", cid)
+	}
+	var logs []models.ClarificationLog
+	if err := query.Order("id ASC").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询答疑记录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
+
+// Stream 以 SSE 推送比赛答疑通知：裁判收到全部通知，选手收到自己提问的回复与公开的答疑
+func (cl *ClarificationController) Stream(c *gin.Context) {
+	op, ok := requireOperatorUUID(cl.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cl.contests.findContest(c)
+	if !ok {
+		return
+	}
+	jury := isJury(op)
+	sub := cl.events.Subscribe(func(ev services.JudgeEvent) bool {
+		if ev.Type != services.EventClarification || ev.ContestID != contest.ID {
+			return false
+		}
+		return jury || ev.Status == "broadcast" || (ev.Status == "answered" && ev.UserID == op)
+	})
+	defer cl.events.Unsubscribe(sub)
+
+	prepareSSE(c)
+	streamEvents(c, sub, false, nil)
+}
@@ -19,12 +19,18 @@ func canAccessUserState(operatorUUID, targetUUID string) bool {
 	return util.UserInstance.HasPermission(operatorUUID, "admin")
 }
 
-// requireOperatorUUID 从请求头或查询参数中获取操作人UUID并验证
-func requireOperatorUUID(db *gorm.DB, c *gin.Context) (string, bool) {
+// operatorUUIDFromRequest 从请求头或查询参数中读取操作人UUID（可能为空，不做校验）
+func operatorUUIDFromRequest(c *gin.Context) string {
 	op := strings.TrimSpace(c.GetHeader("X-User-UUID"))
 	if op == "" {
 		op = strings.TrimSpace(c.Query("operator_uuid"))
 	}
+	return op
+}
+
+// requireOperatorUUID 从请求头或查询参数中获取操作人UUID并验证
+func requireOperatorUUID(db *gorm.DB, c *gin.Context) (string, bool) {
+	op := operatorUUIDFromRequest(c)
 	if op == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
 		return "", false
@@ -41,3 +47,16 @@ func requireOperatorUUID(db *gorm.DB, c *gin.Context) (string, bool) {
 	}
 	return op, true
 }
+
+// requireAdmin 校验操作人已登录且拥有管理员权限
+func requireAdmin(db *gorm.DB, c *gin.Context) (string, bool) {
+	op, ok := requireOperatorUUID(db, c)
+	if !ok {
+		return "", false
+	}
+	if !util.UserInstance.HasPermission(op, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return "", false
+	}
+	return op, true
+}
@@ -0,0 +1,730 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
+	"dachuang/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContestController 比赛管理、报名、比赛题目与榜单
+type ContestController struct {
+	db          *gorm.DB
+	questions   QuestionController
+	submissions *SubmissionController
+	scoreboard  *services.ScoreboardService
+}
+
+// NewContestController 创建比赛控制器；榜单通过 submissions 的评测事件增量更新
+func NewContestController(db *gorm.DB, ossClient *oss.OSS, submissions *SubmissionController) *ContestController {
+	return &ContestController{
+		db:          db,
+		questions:   *NewQuestionController(db, ossClient),
+		submissions: submissions,
+		scoreboard:  services.NewScoreboardService(db, submissions.judgeService.Events),
+	}
+}
+
+// ContestProblemRequest 比赛题目
+type ContestProblemRequest struct {
+	Label          string `json:"label"` // 为空时按顺序生成 A、B、C…
+	QuestionNumber int    `json:"question_number" binding:"required"`
+}
+
+// ContestRequest 创建/更新比赛请求
+type ContestRequest struct {
+	Title         string                  `json:"title" binding:"required"`
+	Description   string                  `json:"description"`
+	StartTime     time.Time               `json:"start_time" binding:"required"`
+	EndTime       time.Time               `json:"end_time" binding:"required"`
+	Rule          string                  `json:"rule"`         // icpc(默认)/oi/ioi
+	Visibility    string                  `json:"visibility"`   // public(默认)/private
+	Registration  string                  `json:"registration"` // open(默认)/password/closed
+	Password      string                  `json:"password"`
+	FreezeMinutes int                     `json:"freeze_minutes"` // 结束前多少分钟封榜，0 表示不封榜
+	Rated         bool                    `json:"rated"`          // 是否为计分比赛（计入 rating）
+	Problems      []ContestProblemRequest `json:"problems"`       // 更新时为空表示保持不变
+}
+
+// contestView 比赛列表与详情的返回结构
+type contestView struct {
+	models.Contest
+	State      string `json:"state"`
+	Registered bool   `json:"registered"`
+}
+
+// contestProblemView 比赛题目列表项
+type contestProblemView struct {
+	Label          string `json:"label"`
+	QuestionNumber int    `json:"question_number"`
+	Title          string `json:"title"`
+}
+
+// contestLabelPattern 题号只允许大写字母与数字
+var contestLabelPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,7}$`)
+
+// contestLabel 第 i 道题的默认题号：A…Z，之后为 A1…Z1
+func contestLabel(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return fmt.Sprintf("%c%d", 'A'+i%26, i/26)
+}
+
+// bindContest 解析并校验比赛请求
+func bindContest(c *gin.Context) (*ContestRequest, bool) {
+	var req ContestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Visibility == "" {
+		req.Visibility = models.ContestPublic
+	}
+	if req.Registration == "" {
+		req.Registration = models.RegistrationOpen
+	}
+	if req.Rule == "" {
+		req.Rule = models.ContestRuleICPC
+	}
+
+	var msg string
+	switch {
+	case req.Title == "":
+		msg = "比赛标题不能为空"
+	case !req.EndTime.After(req.StartTime):
+		msg = "结束时间必须晚于开始时间"
+	case req.Visibility != models.ContestPublic && req.Visibility != models.ContestPrivate:
+		msg = "visibility 仅支持 public/private"
+	case req.Registration != models.RegistrationOpen && req.Registration != models.RegistrationPassword && req.Registration != models.RegistrationClosed:
+		msg = "registration 仅支持 open/password/closed"
+	case req.Rule != models.ContestRuleICPC && req.Rule != models.ContestRuleOI && req.Rule != models.ContestRuleIOI:
+		msg = "rule 仅支持 icpc/oi/ioi"
+	case req.FreezeMinutes != 0 && req.Rule != models.ContestRuleICPC:
+		msg = "封榜仅适用于 icpc 赛制"
+	case req.FreezeMinutes < 0 || time.Duration(req.FreezeMinutes)*time.Minute >= req.EndTime.Sub(req.StartTime):
+		msg = "封榜时长必须小于比赛时长"
+	}
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return nil, false
+	}
+	return &req, true
+}
+
+// buildProblems 把题目编号转换为比赛题目，校验题号与题目不重复
+func (cc *ContestController) buildProblems(reqs []ContestProblemRequest) ([]models.ContestProblem, error) {
+	problems := make([]models.ContestProblem, 0, len(reqs))
+	labels := make(map[string]bool)
+	questions := make(map[int]bool)
+	for i, p := range reqs {
+		label := strings.ToUpper(strings.TrimSpace(p.Label))
+		if label == "" {
+			label = contestLabel(i)
+		}
+		if !contestLabelPattern.MatchString(label) {
+			return nil, fmt.Errorf("题号 %q 无效", p.Label)
+		}
+		if labels[label] {
+			return nil, fmt.Errorf("题号 %s 重复", label)
+		}
+		var question models.Question
+		if err := cc.db.Where("question_number = ?", p.QuestionNumber).First(&question).Error; err != nil {
+			return nil, fmt.Errorf("题目 %d 不存在", p.QuestionNumber)
+		}
+		if questions[question.Id] {
+			return nil, fmt.Errorf("题目 %d 重复", p.QuestionNumber)
+		}
+		labels[label] = true
+		questions[question.Id] = true
+		problems = append(problems, models.ContestProblem{Label: label, QuestionID: question.Id})
+	}
+	return problems, nil
+}
+
+// findContest 按路径参数查找比赛，viewer 不可见的私有比赛按不存在处理
+func (cc *ContestController) findContest(c *gin.Context) (*models.Contest, bool) {
+	var contest models.Contest
+	if err := cc.db.Where("id = ?", c.Param("id")).First(&contest).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return nil, false
+	}
+	op := operatorUUIDFromRequest(c)
+	if contest.Visibility == models.ContestPrivate && !util.UserInstance.HasPermission(op, "admin") &&
+		(op == "" || !models.IsRegistered(cc.db, contest.ID, op)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return nil, false
+	}
+	return &contest, true
+}
+
+// problemViews 比赛题目列表（按题号排序）
+func (cc *ContestController) problemViews(contestID uint) ([]contestProblemView, error) {
+	views := []contestProblemView{}
+	err := cc.db.Model(&models.ContestProblem{}).
+		Select("contest_problems.label, question.question_number, question.title").
+		Joins("JOIN question ON question.id = contest_problems.question_id").
+		Where("contest_problems.contest_id = ?", contestID).
+		Order("LENGTH(contest_problems.label) ASC, contest_problems.label ASC").
+		Scan(&views).Error
+	return views, err
+}
+
+// Index 比赛列表，state 可选 upcoming/running/ended；普通用户只能看到公开比赛与已报名的私有比赛
+func (cc *ContestController) Index(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	op := operatorUUIDFromRequest(c)
+	now := time.Now()
+	query := cc.db.Model(&models.Contest{})
+	if !util.UserInstance.HasPermission(op, "admin") {
+		query = query.Where("visibility = ? OR id IN (?)", models.ContestPublic,
+			cc.db.Model(&models.ContestRegistration{}).Select("contest_id").Where("user_id = ?", op))
+	}
+	switch c.Query("state") {
+	case "":
+	case models.ContestUpcoming:
+		query = query.Where("start_time > ?", now)
+	case models.ContestRunning:
+		query = query.Where("start_time <= ? AND end_time > ?", now, now)
+	case models.ContestEnded:
+		query = query.Where("end_time <= ?", now)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state 仅支持 upcoming/running/ended"})
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询比赛失败"})
+		return
+	}
+	var contests []models.Contest
+	if err := query.Order("start_time DESC").Limit(size).Offset(size * (page - 1)).Find(&contests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询比赛失败"})
+		return
+	}
+
+	registered := make(map[uint]bool)
+	if op != "" && len(contests) > 0 {
+		ids := make([]uint, len(contests))
+		for i, contest := range contests {
+			ids[i] = contest.ID
+		}
+		var regs []uint
+		cc.db.Model(&models.ContestRegistration{}).Where("user_id = ? AND contest_id IN ?", op, ids).Pluck("contest_id", &regs)
+		for _, id := range regs {
+			registered[id] = true
+		}
+	}
+	items := make([]contestView, len(contests))
+	for i, contest := range contests {
+		items[i] = contestView{Contest: contest, State: contest.State(now), Registered: registered[contest.ID]}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "total": total, "page": page, "size": size})
+}
+
+// Show 比赛详情；比赛开始前只有管理员能看到题目列表
+func (cc *ContestController) Show(c *gin.Context) {
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	op := operatorUUIDFromRequest(c)
+	now := time.Now()
+	response := gin.H{"data": contestView{
+		Contest:    *contest,
+		State:      contest.State(now),
+		Registered: op != "" && models.IsRegistered(cc.db, contest.ID, op),
+	}}
+	if contest.State(now) != models.ContestUpcoming || util.UserInstance.HasPermission(op, "admin") {
+		problems, err := cc.problemViews(contest.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询比赛题目失败"})
+			return
+		}
+		response["problems"] = problems
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ShowProblem 比赛题目详情（按题号），比赛开始后可见
+func (cc *ContestController) ShowProblem(c *gin.Context) {
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	if contest.State(time.Now()) == models.ContestUpcoming && !util.UserInstance.HasPermission(operatorUUIDFromRequest(c), "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "比赛尚未开始"})
+		return
+	}
+	var problem models.ContestProblem
+	if err := cc.db.Where("contest_id = ? AND label = ?", contest.ID, strings.ToUpper(c.Param("label"))).First(&problem).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+		return
+	}
+	var question models.Question
+	if err := cc.db.Where("id = ?", problem.QuestionID).First(&question).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+		return
+	}
+	response := cc.questions.detail(&question)
+	response["label"] = problem.Label
+	response["contest_id"] = contest.ID
+	c.JSON(http.StatusOK, response)
+}
+
+// Store 创建比赛（仅管理员）
+func (cc *ContestController) Store(c *gin.Context) {
+	op, ok := requireAdmin(cc.db, c)
+	if !ok {
+		return
+	}
+	req, ok := bindContest(c)
+	if !ok {
+		return
+	}
+	if req.Registration == models.RegistrationPassword && req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报名方式为 password 时需设置密码"})
+		return
+	}
+	problems, err := cc.buildProblems(req.Problems)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contest := models.Contest{
+		Title:         req.Title,
+		Description:   req.Description,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		Visibility:    req.Visibility,
+		Registration:  req.Registration,
+		Password:      req.Password,
+		Rule:          req.Rule,
+		FreezeMinutes: req.FreezeMinutes,
+		Rated:         req.Rated,
+		CreatedBy:     op,
+		Problems:      problems,
+	}
+	if err := cc.db.Create(&contest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建比赛失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": contest, "msg": "比赛创建成功"})
+}
+
+// Update 更新比赛（仅管理员）；比赛开始后不能修改题目列表
+func (cc *ContestController) Update(c *gin.Context) {
+	if _, ok := requireAdmin(cc.db, c); !ok {
+		return
+	}
+	var contest models.Contest
+	if err := cc.db.Where("id = ?", c.Param("id")).First(&contest).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return
+	}
+	req, ok := bindContest(c)
+	if !ok {
+		return
+	}
+	if req.Rule != contest.Rule && contest.State(time.Now()) != models.ContestUpcoming {
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛已开始，不能修改赛制"})
+		return
+	}
+	if req.Rated != contest.Rated && contest.RatedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛已更新 rating，请先撤销"})
+		return
+	}
+	var problems []models.ContestProblem
+	if req.Problems != nil {
+		if contest.State(time.Now()) != models.ContestUpcoming {
+			c.JSON(http.StatusConflict, gin.H{"error": "比赛已开始，不能修改题目"})
+			return
+		}
+		var err error
+		if problems, err = cc.buildProblems(req.Problems); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	contest.Title = req.Title
+	contest.Description = req.Description
+	contest.StartTime = req.StartTime
+	contest.EndTime = req.EndTime
+	contest.Visibility = req.Visibility
+	contest.Registration = req.Registration
+	contest.Rule = req.Rule
+	contest.Rated = req.Rated
+	contest.FreezeMinutes = req.FreezeMinutes
+	// 密码留空时保留原密码
+	if req.Password != "" || req.Registration != models.RegistrationPassword {
+		contest.Password = req.Password
+	}
+	if contest.Registration == models.RegistrationPassword && contest.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报名方式为 password 时需设置密码"})
+		return
+	}
+
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Problems").Save(&contest).Error; err != nil {
+			return err
+		}
+		if req.Problems == nil {
+			return nil
+		}
+		if err := tx.Where("contest_id = ?", contest.ID).Delete(&models.ContestProblem{}).Error; err != nil {
+			return err
+		}
+		for i := range problems {
+			problems[i].ContestID = contest.ID
+		}
+		if len(problems) == 0 {
+			return nil
+		}
+		return tx.Create(&problems).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新比赛失败"})
+		return
+	}
+	cc.scoreboard.Invalidate(contest.ID)
+	c.JSON(http.StatusOK, gin.H{"data": contest, "msg": "比赛更新成功"})
+}
+
+// Delete 删除比赛及其题目、报名与虚拟参赛记录（仅管理员），已有提交保留
+func (cc *ContestController) Delete(c *gin.Context) {
+	if _, ok := requireAdmin(cc.db, c); !ok {
+		return
+	}
+	id := c.Param("id")
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("contest_id = ?", id).Delete(&models.ContestProblem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_id = ?", id).Delete(&models.ContestRegistration{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contest_id = ?", id).Delete(&models.VirtualParticipation{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.Contest{})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除比赛失败"})
+	default:
+		if cid, err := strconv.ParseUint(id, 10, 64); err == nil {
+			cc.scoreboard.Invalidate(uint(cid))
+		}
+		c.JSON(http.StatusOK, gin.H{"msg": "比赛已删除"})
+	}
+}
+
+// ContestRegisterRequest 比赛报名请求
+type ContestRegisterRequest struct {
+	Password string `json:"password"`
+}
+
+// Register 报名比赛，比赛结束前均可报名
+func (cc *ContestController) Register(c *gin.Context) {
+	op, ok := requireOperatorUUID(cc.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	var req ContestRegisterRequest
+	_ = c.ShouldBindJSON(&req)
+
+	switch {
+	case contest.State(time.Now()) == models.ContestEnded:
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛已结束"})
+		return
+	case contest.Registration == models.RegistrationClosed:
+		c.JSON(http.StatusForbidden, gin.H{"error": "比赛不开放报名"})
+		return
+	case contest.Registration == models.RegistrationPassword && req.Password != contest.Password:
+		c.JSON(http.StatusForbidden, gin.H{"error": "报名密码错误"})
+		return
+	}
+
+	reg := models.ContestRegistration{ContestID: contest.ID, UserID: op}
+	if err := cc.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "报名失败"})
+		return
+	}
+	cc.scoreboard.Invalidate(contest.ID)
+	c.JSON(http.StatusOK, gin.H{"msg": "报名成功"})
+}
+
+// Unregister 取消报名，比赛开始后不能取消
+func (cc *ContestController) Unregister(c *gin.Context) {
+	op, ok := requireOperatorUUID(cc.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	if contest.State(time.Now()) != models.ContestUpcoming {
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛已开始，不能取消报名"})
+		return
+	}
+	if err := cc.db.Where("contest_id = ? AND user_id = ?", contest.ID, op).Delete(&models.ContestRegistration{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消报名失败"})
+		return
+	}
+	cc.scoreboard.Invalidate(contest.ID)
+	c.JSON(http.StatusOK, gin.H{"msg": "已取消报名"})
+}
+
+// ListRegistrations 比赛报名列表（仅管理员）
+func (cc *ContestController) ListRegistrations(c *gin.Context) {
+	if _, ok := requireAdmin(cc.db, c); !ok {
+		return
+	}
+	var regs []models.ContestRegistration
+	if err := cc.db.Where("contest_id = ?", c.Param("id")).Order("id ASC").Find(&regs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询报名失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": regs, "total": len(regs)})
+}
+
+// AddRegistrationsRequest 管理员批量添加报名
+type AddRegistrationsRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required"`
+}
+
+// AddRegistrations 管理员为用户报名（不受报名方式限制）
+func (cc *ContestController) AddRegistrations(c *gin.Context) {
+	if _, ok := requireAdmin(cc.db, c); !ok {
+		return
+	}
+	var contest models.Contest
+	if err := cc.db.Where("id = ?", c.Param("id")).First(&contest).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return
+	}
+	var req AddRegistrationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var users []string
+	if err := cc.db.Model(&models.User{}).Where("uuid IN ?", req.UserIDs).Pluck("uuid", &users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询用户失败"})
+		return
+	}
+	regs := make([]models.ContestRegistration, len(users))
+	for i, uid := range users {
+		regs[i] = models.ContestRegistration{ContestID: contest.ID, UserID: uid}
+	}
+	if len(regs) > 0 {
+		if err := cc.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&regs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "添加报名失败"})
+			return
+		}
+		cc.scoreboard.Invalidate(contest.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "添加成功", "added": len(users), "not_found": len(req.UserIDs) - len(users)})
+}
+
+// checkContestSubmission 校验比赛提交：题目属于该比赛、处于比赛时间内、提交者已报名（管理员除外）；
+// 比赛结束后处于虚拟参赛时间内的提交为虚拟提交，virtual 为真。校验通过时 msg 为空
+func checkContestSubmission(db *gorm.DB, contestID uint, userID string, questionID int, isAdmin bool) (contest *models.Contest, virtual bool, status int, msg string) {
+	contest = &models.Contest{}
+	if err := db.Where("id = ?", contestID).First(contest).Error; err != nil {
+		return nil, false, http.StatusNotFound, "比赛不存在"
+	}
+	var count int64
+	db.Model(&models.ContestProblem{}).Where("contest_id = ? AND question_id = ?", contestID, questionID).Count(&count)
+	if count == 0 {
+		return nil, false, http.StatusBadRequest, "题目不属于该比赛"
+	}
+	now := time.Now()
+	if contest.State(now) == models.ContestEnded {
+		if vp, ok := findVirtual(db, contestID, userID); ok && vp.Running(now) {
+			return contest, true, 0, ""
+		}
+	}
+	if contest.State(now) != models.ContestRunning {
+		return nil, false, http.StatusForbidden, "不在比赛时间内"
+	}
+	if !isAdmin && !models.IsRegistered(db, contestID, userID) {
+		return nil, false, http.StatusForbidden, "未报名该比赛"
+	}
+	return contest, false, 0, ""
+}
+
+// frozenForViewer 封榜期间他人在封榜后的正式提交对 viewer 隐藏评测结果，滚榜已公布的格子除外；调用方负责排除管理员
+func frozenForViewer(db *gorm.DB, contest *models.Contest, submission *models.Submission, viewer string) bool {
+	if submission.Virtual || submission.UserID == viewer || !contest.IsFrozen(time.Now()) || submission.CreatedAt.Before(contest.FreezeTime()) {
+		return false
+	}
+	var revealed int64
+	db.Model(&models.ContestReveal{}).
+		Where("contest_id = ? AND user_id = ? AND question_id = ?", contest.ID, submission.UserID, submission.QuestionID).
+		Count(&revealed)
+	return revealed == 0
+}
+
+// Scoreboard 比赛榜单；封榜期间选手看到封榜视图，管理员默认看到实时榜单，传 view=public 可查看选手视图
+// oi 赛制在最终评测开始前不向选手公布榜单
+func (cc *ContestController) Scoreboard(c *gin.Context) {
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	isAdmin := util.UserInstance.HasPermission(operatorUUIDFromRequest(c), "admin")
+	if contest.State(time.Now()) == models.ContestUpcoming && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "比赛尚未开始"})
+		return
+	}
+	if contest.ResultsHidden() && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "榜单将在最终评测后公布"})
+		return
+	}
+	board, err := cc.scoreboard.Board(contest.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加载榜单失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": board.Standings(!isAdmin || c.Query("view") == "public")})
+}
+
+// requireEndedContest 管理员操作已结束的比赛（滚榜、解除封榜）
+func (cc *ContestController) requireEndedContest(c *gin.Context) (*models.Contest, bool) {
+	if _, ok := requireAdmin(cc.db, c); !ok {
+		return nil, false
+	}
+	var contest models.Contest
+	if err := cc.db.Where("id = ?", c.Param("id")).First(&contest).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return nil, false
+	}
+	if contest.State(time.Now()) != models.ContestEnded {
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛尚未结束"})
+		return nil, false
+	}
+	return &contest, true
+}
+
+// Resolve 滚榜：从封榜视图中排名最靠后的选手开始，每次公布一个格子的结果（管理员）
+// 全部公布后比赛自动解除封榜
+func (cc *ContestController) Resolve(c *gin.Context) {
+	contest, ok := cc.requireEndedContest(c)
+	if !ok {
+		return
+	}
+	result, err := cc.scoreboard.Reveal(contest.ID)
+	switch {
+	case errors.Is(err, services.ErrNothingToReveal):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	}
+}
+
+// Unfreeze 直接解除封榜，公布全部结果（管理员）
+func (cc *ContestController) Unfreeze(c *gin.Context) {
+	contest, ok := cc.requireEndedContest(c)
+	if !ok {
+		return
+	}
+	if err := cc.scoreboard.Unfreeze(contest.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已解除封榜"})
+}
+
+// FinalJudge oi 赛制最终评测（管理员）：对每位选手每道题在比赛时间内的最后一次提交进行完整评测，
+// 评测开始后向选手公布结果与榜单；重复调用会重新评测同一批提交
+func (cc *ContestController) FinalJudge(c *gin.Context) {
+	contest, ok := cc.requireEndedContest(c)
+	if !ok {
+		return
+	}
+	if contest.Rule != models.ContestRuleOI {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅 oi 赛制需要最终评测"})
+		return
+	}
+
+	// 每位报名选手每道题的最后一次提交
+	last := cc.db.Model(&models.Submission{}).
+		Select("MAX(submissions.created_at)").
+		Where("s2.contest_id = submissions.contest_id AND s2.user_id = submissions.user_id AND s2.question_id = submissions.question_id").
+		Where("submissions.status <> ? AND submissions.created_at >= ? AND submissions.created_at < ?", statusCancelled, contest.StartTime, contest.EndTime)
+	var submissions []*models.Submission
+	if err := cc.db.Table("submissions AS s2").
+		Where("s2.contest_id = ? AND s2.status <> ?", contest.ID, statusCancelled).
+		Where("s2.user_id IN (?)", cc.db.Model(&models.ContestRegistration{}).Select("user_id").Where("contest_id = ?", contest.ID)).
+		Where("s2.created_at = (?)", last).
+		Select("s2.*").Order("s2.created_at ASC").Find(&submissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询比赛提交失败"})
+		return
+	}
+
+	now := time.Now()
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		for _, submission := range submissions {
+			submission.JudgeMode = models.JudgeModeFull
+			submission.Status = "pending"
+			submission.Verdict = ""
+			submission.Results = ""
+			submission.Score = 0
+			submission.SubtaskScores = ""
+			submission.ErrorCode = ""
+			submission.ErrorMsg = ""
+			if err := tx.Save(submission).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(contest).Update("final_judged_at", now).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发起最终评测失败"})
+		return
+	}
+	cc.scoreboard.Invalidate(contest.ID)
+	for _, submission := range submissions {
+		cc.submissions.enqueue(submission, services.PriorityContest)
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(submissions), "final_judged_at": now, "msg": "最终评测已加入评测队列"})
+}
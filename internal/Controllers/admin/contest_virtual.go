@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"dachuang/internal/models"
+	"dachuang/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findVirtual 查询用户在比赛中的虚拟参赛记录
+func findVirtual(db *gorm.DB, contestID uint, userID string) (*models.VirtualParticipation, bool) {
+	var vp models.VirtualParticipation
+	if userID == "" || db.Where("contest_id = ? AND user_id = ?", contestID, userID).First(&vp).Error != nil {
+		return nil, false
+	}
+	return &vp, true
+}
+
+// contestResultsHidden 比赛提交的评测结果是否对选手隐藏：oi 赛制正式提交在最终评测前隐藏，虚拟提交在虚拟比赛结束前隐藏
+func contestResultsHidden(db *gorm.DB, contest *models.Contest, submission *models.Submission) bool {
+	if !submission.Virtual {
+		return contest.ResultsHidden()
+	}
+	if contest.Rule != models.ContestRuleOI {
+		return false
+	}
+	vp, ok := findVirtual(db, contest.ID, submission.UserID)
+	return ok && time.Now().Before(vp.EndTime)
+}
+
+// virtualView 虚拟参赛记录及状态
+type virtualView struct {
+	models.VirtualParticipation
+	State     string `json:"state"`     // running / ended
+	Remaining int64  `json:"remaining"` // 剩余秒数
+}
+
+// newVirtualView 虚拟参赛在 now 时刻的状态
+func newVirtualView(vp *models.VirtualParticipation, now time.Time) virtualView {
+	view := virtualView{VirtualParticipation: *vp, State: models.ContestEnded}
+	if vp.Running(now) {
+		view.State = models.ContestRunning
+		view.Remaining = int64(vp.EndTime.Sub(now).Seconds())
+	}
+	return view
+}
+
+// StartVirtual 开始虚拟参赛：比赛结束后按原比赛时长开始个人计时，期间以 contest_id 提交的代码记为虚拟提交
+// 正式参赛（已报名）的用户不能虚拟参赛，每人每场只能虚拟参赛一次
+func (cc *ContestController) StartVirtual(c *gin.Context) {
+	op, ok := requireOperatorUUID(cc.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	switch {
+	case contest.State(now) != models.ContestEnded:
+		c.JSON(http.StatusConflict, gin.H{"error": "比赛结束后才能虚拟参赛"})
+		return
+	case models.IsRegistered(cc.db, contest.ID, op):
+		c.JSON(http.StatusConflict, gin.H{"error": "已正式参加该比赛"})
+		return
+	}
+	if _, exists := findVirtual(cc.db, contest.ID, op); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "已虚拟参加过该比赛"})
+		return
+	}
+
+	vp := models.VirtualParticipation{
+		ContestID: contest.ID,
+		UserID:    op,
+		StartTime: now,
+		EndTime:   now.Add(contest.EndTime.Sub(contest.StartTime)),
+	}
+	if err := cc.db.Create(&vp).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开始虚拟参赛失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": newVirtualView(&vp, now), "msg": "虚拟参赛已开始"})
+}
+
+// ShowVirtual 当前用户的虚拟参赛记录
+func (cc *ContestController) ShowVirtual(c *gin.Context) {
+	op, ok := requireOperatorUUID(cc.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	vp, exists := findVirtual(cc.db, contest.ID, op)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未虚拟参加该比赛"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": newVirtualView(vp, time.Now())})
+}
+
+// VirtualScoreboard 虚拟参赛榜单：原参赛选手截至相同相对时间的结果，加上虚拟选手的成绩
+// 默认查看自己的虚拟参赛，管理员可通过 user_id 查看其他用户
+func (cc *ContestController) VirtualScoreboard(c *gin.Context) {
+	op, ok := requireOperatorUUID(cc.db, c)
+	if !ok {
+		return
+	}
+	contest, ok := cc.findContest(c)
+	if !ok {
+		return
+	}
+	userID := op
+	isAdmin := util.UserInstance.HasPermission(op, "admin")
+	if u := c.Query("user_id"); u != "" && isAdmin {
+		userID = u
+	}
+	vp, exists := findVirtual(cc.db, contest.ID, userID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未虚拟参加该比赛"})
+		return
+	}
+	now := time.Now()
+	if contest.Rule == models.ContestRuleOI && vp.Running(now) && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "榜单将在虚拟比赛结束后公布"})
+		return
+	}
+
+	standings, err := cc.scoreboard.VirtualStandings(contest.ID, vp, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加载榜单失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": standings, "virtual": newVirtualView(vp, now)})
+}
@@ -0,0 +1,200 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GeneratorController 数据生成器与生成脚本管理（仅管理员）
+type GeneratorController struct {
+	db     *gorm.DB
+	setter *services.ProblemSetterService
+}
+
+// NewGeneratorController 创建数据生成器控制器
+func NewGeneratorController(db *gorm.DB, ossClient *oss.OSS) *GeneratorController {
+	return &GeneratorController{db: db, setter: newProblemSetter(db, ossClient)}
+}
+
+// generatorNamePattern 生成器名只允许字母、数字、下划线和连字符，便于在脚本中引用
+var generatorNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// GeneratorRequest 创建/更新生成器请求
+type GeneratorRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Language string `json:"language"` // 为空时自动识别
+	Code     string `json:"code" binding:"required"`
+}
+
+// GeneratorScriptRequest 保存生成脚本请求
+type GeneratorScriptRequest struct {
+	Content string `json:"content" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// GenerateTestsRequest 运行生成脚本请求
+type GenerateTestsRequest struct {
+	Version int `json:"version"` // 0 表示最新版本
+}
+
+// Index 生成器列表
+func (gc *GeneratorController) Index(c *gin.Context) {
+	if _, ok := requireAdmin(gc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+
+	var generators []models.Generator
+	if err := gc.db.Where("question_id = ?", question.Id).Order("name ASC").Find(&generators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询生成器失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": generators})
+}
+
+// Store 添加生成器，同名时覆盖代码；最新版本的生成脚本引用了该生成器时自动保存为新版本
+func (gc *GeneratorController) Store(c *gin.Context) {
+	op, ok := requireAdmin(gc.db, c)
+	if !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+	var req GeneratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if !generatorNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "生成器名只能包含字母、数字、下划线和连字符"})
+		return
+	}
+
+	var gen models.Generator
+	err := gc.db.Where("question_id = ? AND name = ?", question.Id, req.Name).First(&gen).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询生成器失败"})
+		return
+	}
+	status := http.StatusOK
+	if gen.ID == 0 {
+		status = http.StatusCreated
+	}
+	gen.QuestionID = question.Id
+	gen.Name = req.Name
+	gen.Language = req.Language
+	gen.Code = req.Code
+	if err := gc.db.Save(&gen).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存生成器失败: " + err.Error()})
+		return
+	}
+	script, err := gc.setter.GeneratorUpdated(question.Id, gen.Name, op)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成器已保存，但" + err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"data": gen, "script": script, "msg": "生成器保存成功"})
+}
+
+// Delete 删除生成器
+func (gc *GeneratorController) Delete(c *gin.Context) {
+	if _, ok := requireAdmin(gc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+	result := gc.db.Where("id = ? AND question_id = ?", c.Param("id"), question.Id).Delete(&models.Generator{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除生成器失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "生成器不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "生成器删除成功"})
+}
+
+// Scripts 生成脚本的全部版本（新版本在前）
+func (gc *GeneratorController) Scripts(c *gin.Context) {
+	if _, ok := requireAdmin(gc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+
+	var scripts []models.GeneratorScript
+	if err := gc.db.Where("question_id = ?", question.Id).Order("version DESC").Find(&scripts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询生成脚本失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": scripts})
+}
+
+// SaveScript 保存生成脚本为新版本，同时保存引用的生成器代码；引用不存在的生成器时返回 400
+func (gc *GeneratorController) SaveScript(c *gin.Context) {
+	op, ok := requireAdmin(gc.db, c)
+	if !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+	var req GeneratorScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	script, calls, err := gc.setter.SaveGeneratorScript(question.Id, req.Content, req.Comment, op)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": script, "calls": calls, "msg": "生成脚本已保存"})
+}
+
+// Generate 运行生成脚本，生成输入并用主参考解生成输出
+func (gc *GeneratorController) Generate(c *gin.Context) {
+	if _, ok := requireAdmin(gc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(gc.db, c)
+	if !ok {
+		return
+	}
+	var req GenerateTestsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := gc.setter.RunGenerators(context.Background(), question, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report, "validation_status": question.ValidationStatus})
+}
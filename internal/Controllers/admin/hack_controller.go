@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HackController 处理 hack（用自造输入挑战他人已通过的提交）
+type HackController struct {
+	db          *gorm.DB
+	service     *services.HackService
+	submissions *SubmissionController
+}
+
+// NewHackController 创建 hack 控制器；成功的 hack 加入测试数据后通过 submissions 重新评测
+func NewHackController(db *gorm.DB, ossClient *oss.OSS, submissions *SubmissionController) *HackController {
+	return &HackController{
+		db:          db,
+		service:     services.NewHackService(db, newProblemSetter(db, ossClient)),
+		submissions: submissions,
+	}
+}
+
+// HackRequest 发起 hack 请求
+type HackRequest struct {
+	TargetSubmissionID string `json:"target_submission_id" binding:"required"`
+	Input              string `json:"input" binding:"required"`
+}
+
+// Store 发起 hack：发起人需已通过该题，目标必须是他人已通过的完整评测练习提交，
+// 题目属于尚未结束的比赛时不能 hack
+func (hc *HackController) Store(c *gin.Context) {
+	op, ok := requireOperatorUUID(hc.db, c)
+	if !ok {
+		return
+	}
+	var req HackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Input) > services.MaxHackInputSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hack 输入过大"})
+		return
+	}
+
+	var target models.Submission
+	if err := hc.db.Where("id = ?", req.TargetSubmissionID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "目标提交不存在"})
+		return
+	}
+	if target.Verdict != models.VerdictAccepted || target.JudgeMode != models.JudgeModeFull {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能 hack 已通过的提交"})
+		return
+	}
+	if target.ContestID != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能 hack 练习提交"})
+		return
+	}
+	if models.InUnfinishedContest(hc.db, target.QuestionID, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "比赛进行中的题目不能 hack"})
+		return
+	}
+	if target.FileCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "暂不支持 hack 多文件提交"})
+		return
+	}
+	if target.UserID == op {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能 hack 自己的提交"})
+		return
+	}
+
+	var solved int64
+	hc.db.Model(&models.Submission{}).
+		Where("user_id = ? AND question_id = ? AND verdict = ? AND judge_mode = ?", op, target.QuestionID, models.VerdictAccepted, models.JudgeModeFull).
+		Count(&solved)
+	if solved == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "通过该题后才能发起 hack"})
+		return
+	}
+
+	hack := models.Hack{
+		QuestionID:         target.QuestionID,
+		HackerID:           op,
+		TargetSubmissionID: target.ID,
+		TargetUserID:       target.UserID,
+		Input:              req.Input,
+	}
+	if err := hc.service.Start(&hack); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": hack, "msg": "hack 已提交"})
+}
+
+// Index hack 列表，支持按题目编号、发起人、目标提交、状态过滤（不含输入输出）
+func (hc *HackController) Index(c *gin.Context) {
+	query := hc.db.Model(&models.Hack{}).Omit("input", "expected_output", "actual_output")
+	if numberStr := strings.TrimSpace(c.Query("question_number")); numberStr != "" {
+		number, err := strconv.Atoi(numberStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的题目编号"})
+			return
+		}
+		var question models.Question
+		if err := hc.db.Where("question_number = ?", number).First(&question).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+			return
+		}
+		query = query.Where("question_id = ?", question.Id)
+	}
+	if v := strings.TrimSpace(c.Query("hacker_id")); v != "" {
+		query = query.Where("hacker_id = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("target_submission_id")); v != "" {
+		query = query.Where("target_submission_id = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		query = query.Where("status = ?", v)
+	}
+
+	var hacks []models.Hack
+	if err := query.Order("id DESC").Limit(200).Find(&hacks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询 hack 失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hacks})
+}
+
+// Show hack 详情：输入、标准答案与目标输出仅发起人、目标提交者与管理员可见
+func (hc *HackController) Show(c *gin.Context) {
+	var hack models.Hack
+	if err := hc.db.Where("id = ?", c.Param("id")).First(&hack).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hack 不存在"})
+		return
+	}
+	op := operatorUUIDFromRequest(c)
+	if op == "" || (op != hack.HackerID && !canAccessUserState(op, hack.TargetUserID)) {
+		hack.Input = ""
+		hack.ExpectedOutput = ""
+		hack.ActualOutput = ""
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hack})
+}
+
+// AddToTests 将成功的 hack 加入测试数据，并重新评测该题已通过的提交（仅管理员）
+func (hc *HackController) AddToTests(c *gin.Context) {
+	if _, ok := requireAdmin(hc.db, c); !ok {
+		return
+	}
+	var hack models.Hack
+	if err := hc.db.Where("id = ?", c.Param("id")).First(&hack).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hack 不存在"})
+		return
+	}
+
+	testCase, err := hc.service.AddToTests(&hack)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rejudged, err := hc.submissions.RejudgeAccepted(hack.QuestionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "测试用例已添加，但重新评测失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": testCase, "rejudged": rejudged, "msg": "已加入测试数据并开始重新评测"})
+}
@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// InputValidatorController 输入校验器管理（仅管理员）
+type InputValidatorController struct {
+	db     *gorm.DB
+	setter *services.ProblemSetterService
+}
+
+// NewInputValidatorController 创建输入校验器控制器
+func NewInputValidatorController(db *gorm.DB, ossClient *oss.OSS) *InputValidatorController {
+	return &InputValidatorController{db: db, setter: newProblemSetter(db, ossClient)}
+}
+
+// InputValidatorRequest 设置输入校验器请求
+type InputValidatorRequest struct {
+	Language string `json:"language"` // 为空时自动识别
+	Code     string `json:"code" binding:"required"`
+}
+
+// Show 获取题目的输入校验器
+func (vc *InputValidatorController) Show(c *gin.Context) {
+	if _, ok := requireAdmin(vc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(vc.db, c)
+	if !ok {
+		return
+	}
+	validator, err := vc.setter.InputValidator(question.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if validator == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目没有设置输入校验器"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": validator})
+}
+
+// Save 设置输入校验器（覆盖原有），并清除已有的校验状态
+func (vc *InputValidatorController) Save(c *gin.Context) {
+	if _, ok := requireAdmin(vc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(vc.db, c)
+	if !ok {
+		return
+	}
+	var req InputValidatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var validator models.InputValidator
+	err := vc.db.Where("question_id = ?", question.Id).First(&validator).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询输入校验器失败"})
+		return
+	}
+	validator.QuestionID = question.Id
+	validator.Language = req.Language
+	validator.Code = req.Code
+	err = vc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&validator).Error; err != nil {
+			return err
+		}
+		return services.ClearInputValidation(tx, question.Id)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存输入校验器失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": validator, "msg": "输入校验器已保存"})
+}
+
+// Delete 删除输入校验器
+func (vc *InputValidatorController) Delete(c *gin.Context) {
+	if _, ok := requireAdmin(vc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(vc.db, c)
+	if !ok {
+		return
+	}
+	err := vc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", question.Id).Delete(&models.InputValidator{}).Error; err != nil {
+			return err
+		}
+		return services.ClearInputValidation(tx, question.Id)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除输入校验器失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "输入校验器已删除"})
+}
+
+// Run 用校验器检查题目的全部测试输入
+func (vc *InputValidatorController) Run(c *gin.Context) {
+	if _, ok := requireAdmin(vc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(vc.db, c)
+	if !ok {
+		return
+	}
+	summary, err := vc.setter.ValidateTestCases(context.Background(), question.Id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !summary.HasValidator {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目没有设置输入校验器"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
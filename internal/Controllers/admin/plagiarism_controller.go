@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PlagiarismController 代码查重（仅管理员）
+type PlagiarismController struct {
+	db      *gorm.DB
+	service *services.PlagiarismService
+}
+
+// NewPlagiarismController 创建查重控制器
+func NewPlagiarismController(db *gorm.DB) *PlagiarismController {
+	return &PlagiarismController{db: db, service: services.NewPlagiarismService(db)}
+}
+
+// PlagiarismRunRequest 发起查重请求
+type PlagiarismRunRequest struct {
+	Threshold float64 `json:"threshold"` // 相似度阈值 (0,1]，默认 0.6
+}
+
+// bindThreshold 解析并校验阈值
+func bindThreshold(c *gin.Context) (float64, bool) {
+	var req PlagiarismRunRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return 0, false
+		}
+	}
+	if req.Threshold == 0 {
+		req.Threshold = services.DefaultPlagiarismThreshold
+	}
+	if req.Threshold < 0 || req.Threshold > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold 取值范围为 (0, 1]"})
+		return 0, false
+	}
+	return req.Threshold, true
+}
+
+// RunForQuestion 对单个题目的通过提交发起查重
+func (pc *PlagiarismController) RunForQuestion(c *gin.Context) {
+	op, ok := requireAdmin(pc.db, c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的题目编号"})
+		return
+	}
+	var question models.Question
+	if err := pc.db.Where("question_number = ?", number).First(&question).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+		return
+	}
+
+	threshold, ok := bindThreshold(c)
+	if !ok {
+		return
+	}
+
+	report, err := pc.service.StartForQuestion(question.Id, threshold, op)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": report, "msg": "查重任务已开始"})
+}
+
+// RunForContest 对比赛中各题在比赛内的通过提交发起查重
+func (pc *PlagiarismController) RunForContest(c *gin.Context) {
+	op, ok := requireAdmin(pc.db, c)
+	if !ok {
+		return
+	}
+
+	var contest models.Contest
+	if err := pc.db.Where("id = ?", c.Param("id")).First(&contest).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+		return
+	}
+
+	threshold, ok := bindThreshold(c)
+	if !ok {
+		return
+	}
+
+	report, err := pc.service.StartForContest(contest.ID, threshold, op)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": report, "msg": "查重任务已开始"})
+}
+
+// ListReports 查重报告列表，可按题目编号或比赛 ID 过滤
+func (pc *PlagiarismController) ListReports(c *gin.Context) {
+	if _, ok := requireAdmin(pc.db, c); !ok {
+		return
+	}
+
+	query := pc.db.Model(&models.PlagiarismReport{}).Omit("pairs", "clusters", "excluded")
+	if numberStr := strings.TrimSpace(c.Query("question_number")); numberStr != "" {
+		number, err := strconv.Atoi(numberStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的题目编号"})
+			return
+		}
+		var question models.Question
+		if err := pc.db.Where("question_number = ?", number).First(&question).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+			return
+		}
+		query = query.Where("question_id = ?", question.Id)
+	}
+	if contestID := strings.TrimSpace(c.Query("contest_id")); contestID != "" {
+		query = query.Where("contest_id = ?", contestID)
+	}
+
+	var reports []models.PlagiarismReport
+	if err := query.Order("id DESC").Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询查重报告失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reports})
+}
+
+// findReport 按路径参数查找报告
+func (pc *PlagiarismController) findReport(c *gin.Context) (*models.PlagiarismReport, bool) {
+	var report models.PlagiarismReport
+	if err := pc.db.Where("id = ?", c.Param("id")).First(&report).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "查重报告不存在"})
+		return nil, false
+	}
+	return &report, true
+}
+
+// ShowReport 查重报告详情（代码对、匹配片段、聚类与未参与比对的提交）
+func (pc *PlagiarismController) ShowReport(c *gin.Context) {
+	if _, ok := requireAdmin(pc.db, c); !ok {
+		return
+	}
+	report, ok := pc.findReport(c)
+	if !ok {
+		return
+	}
+
+	pairs, clusters := services.DecodePlagiarismReport(report)
+	c.JSON(http.StatusOK, gin.H{"data": report, "pairs": pairs, "clusters": clusters, "excluded": services.DecodePlagiarismExcluded(report)})
+}
+
+// ExportReport 以 CSV 导出查重报告
+func (pc *PlagiarismController) ExportReport(c *gin.Context) {
+	if _, ok := requireAdmin(pc.db, c); !ok {
+		return
+	}
+	report, ok := pc.findReport(c)
+	if !ok {
+		return
+	}
+	if report.Status != models.PlagiarismCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "查重尚未完成"})
+		return
+	}
+
+	pairs, clusters := services.DecodePlagiarismReport(report)
+
+	// 为每个提交标注所属聚类编号
+	clusterOf := make(map[string]int)
+	for i, cl := range clusters {
+		for _, id := range cl.Submissions {
+			clusterOf[id] = i + 1
+		}
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=plagiarism_report_%d.csv", report.ID))
+	c.Status(http.StatusOK)
+	c.Writer.Write([]byte("\xEF\xBB\xBF")) // UTF-8 BOM，便于 Excel 打开
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"cluster", "question_id", "language", "similarity", "submission_a", "user_a", "coverage_a", "submission_b", "user_b", "coverage_b", "regions"})
+	for _, p := range pairs {
+		regions := make([]string, 0, len(p.Regions))
+		for _, r := range p.Regions {
+			regions = append(regions, fmt.Sprintf("A%d-%d/B%d-%d", r.StartLineA, r.EndLineA, r.StartLineB, r.EndLineB))
+		}
+		cluster := ""
+		if id := clusterOf[p.SubmissionA]; id > 0 {
+			cluster = strconv.Itoa(id)
+		}
+		_ = w.Write([]string{
+			cluster,
+			strconv.Itoa(p.QuestionID),
+			p.Language,
+			strconv.FormatFloat(p.Similarity, 'f', 4, 64),
+			p.SubmissionA,
+			p.UserA,
+			strconv.FormatFloat(p.CoverageA, 'f', 4, 64),
+			p.SubmissionB,
+			p.UserB,
+			strconv.FormatFloat(p.CoverageB, 'f', 4, 64),
+			strings.Join(regions, ";"),
+		})
+	}
+	w.Flush()
+}
@@ -1,22 +1,63 @@
 package admin
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"dachuang/internal/config"
+	"dachuang/internal/harness"
 	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
 	"dachuang/internal/util"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"gorm.io/gorm"
 )
 
 type QuestionController struct {
-	db *gorm.DB
+	db        *gorm.DB
+	ossClient *oss.OSS
+	setter    *services.ProblemSetterService
 }
 
-func NewQuestionController(db *gorm.DB) *QuestionController {
-	return &QuestionController{db: db}
+func NewQuestionController(db *gorm.DB, ossClient *oss.OSS) *QuestionController {
+	return &QuestionController{db: db, ossClient: ossClient, setter: newProblemSetter(db, ossClient)}
+}
+
+// SampleCase 题面中展示的样例（与评测使用同一份测试数据）
+type SampleCase struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// loadSamples 读取题目的样例测试用例
+func (con QuestionController) loadSamples(questionID int) ([]SampleCase, error) {
+	var testCases []models.TestCase
+	if err := con.db.Where("question_id = ? AND is_sample = ?", questionID, true).
+		Order("sample_order ASC, id ASC").Find(&testCases).Error; err != nil {
+		return nil, err
+	}
+
+	bucket := config.GlobalConfig.OSS.BucketName
+	if bucket == "" {
+		bucket = "patreon-oj-cases"
+	}
+
+	ctx := context.Background()
+	samples := make([]SampleCase, 0, len(testCases))
+	for _, tc := range testCases {
+		input, output, err := services.LoadTestCaseIO(ctx, con.ossClient, bucket, tc)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, SampleCase{Input: input, Output: output})
+	}
+	return samples, nil
 }
 
 func (con QuestionController) Index(c *gin.Context) {
@@ -41,18 +82,22 @@ func (con QuestionController) Index(c *gin.Context) {
 		difficult = "%"
 	}
 
-	// 分用户组筛选
+	// 分用户组筛选，尚未开始的比赛中的题目对普通用户隐藏
 	status := "%"
+	visible := func(db *gorm.DB) *gorm.DB { return db }
 	if !util.UserInstance.HasPermission(uuid, "admin") {
 		status = "published"
+		visible = func(db *gorm.DB) *gorm.DB {
+			return db.Where("id NOT IN (?)", models.UnstartedContestQuestions(models.DB, time.Now()))
+		}
 	}
 
 	// 先不按照分页，查找一下全部的内容，为了确定在搜索的情况下有多少个元素
-	models.DB.Where("difficulty Like ? and (title Like ? or question_number Like ?) and status Like ?", difficult, q, q, status).Order("question_number ASC").Find(&questionList)
+	models.DB.Scopes(visible).Where("difficulty Like ? and (title Like ? or question_number Like ?) and status Like ?", difficult, q, q, status).Order("question_number ASC").Find(&questionList)
 	totalCnt := len(questionList)
 
 	// 按题目编号排序查询所有题目
-	models.DB.Where("difficulty Like ? and (title Like ? or question_number Like ?) and status Like ?", difficult, q, q, status).Order("question_number ASC").Find(&questionList).Limit(pageSize).Offset(pageSize * (pageIdx - 1)).Find(&questionList)
+	models.DB.Scopes(visible).Where("difficulty Like ? and (title Like ? or question_number Like ?) and status Like ?", difficult, q, q, status).Order("question_number ASC").Find(&questionList).Limit(pageSize).Offset(pageSize * (pageIdx - 1)).Find(&questionList)
 	c.JSON(200, gin.H{
 		"result":   questionList,
 		"pageIdx":  pageIdx,
@@ -69,7 +114,7 @@ func (con QuestionController) GetNewProblems(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "3"))
 
 	// 按题目编号排序查询所有题目
-	models.DB.Where("status = ?", "published").Order("id DESC").Find(&questionList).Limit(pageSize).Offset(pageSize * (pageIdx - 1)).Find(&questionList)
+	models.DB.Where("status = ? AND id NOT IN (?)", "published", models.UnstartedContestQuestions(models.DB, time.Now())).Order("id DESC").Find(&questionList).Limit(pageSize).Offset(pageSize * (pageIdx - 1)).Find(&questionList)
 	c.JSON(200, gin.H{
 		"result":   questionList,
 		"pageIdx":  pageIdx,
@@ -94,6 +139,11 @@ func (con QuestionController) Store(c *gin.Context) {
 		DataRange   string `json:"data_range"`   // 数据范围
 		TimeLimit   int    `json:"time_limit"`   // 时间限制（毫秒）
 		MemoryLimit int    `json:"memory_limit"` // 内存限制（MB）
+		OutputLimit int    `json:"output_limit"` // 输出限制（KB），0 表示自动
+
+		// 文件 IO，为空表示标准输入/输出
+		InputFile  string `json:"input_file"`
+		OutputFile string `json:"output_file"`
 
 		// 元数据
 		Tags string `json:"tags"` // 题目标签（逗号分隔）
@@ -116,10 +166,18 @@ func (con QuestionController) Store(c *gin.Context) {
 	question.Status = request.Status
 	question.TimeLimit = request.TimeLimit
 	question.MemoryLimit = request.MemoryLimit
+	question.OutputLimit = request.OutputLimit
+	question.InputFile = request.InputFile
+	question.OutputFile = request.OutputFile
 	question.Tags = request.Tags
 	question.QuestionId = request.QuestionId
 	question.Content = request.Content
 
+	if msg, ok := checkIOFiles(question.InputFile, question.OutputFile); !ok {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
 	// 处理题目编号逻辑
 	if question.QuestionNumber == 0 {
 		// 如果没有提供题目编号，自动生成
@@ -139,8 +197,8 @@ func (con QuestionController) Store(c *gin.Context) {
 		}
 	}
 
-	// 设置默认值
-	if question.Status == "" {
+	// 设置默认值；新题目还没有参考解与测试数据，一律先保存为草稿，发布需通过更新接口的发布检查
+	if question.Status == "" || question.Status == "published" {
 		question.Status = "draft"
 	}
 	if question.TimeLimit == 0 {
@@ -182,8 +240,39 @@ func (con QuestionController) Show(c *gin.Context) {
 		return
 	}
 
-	// 4. 返回题目详情
-	c.JSON(http.StatusOK, gin.H{"data": question})
+	// 4. 尚未开始的比赛中的题目对普通用户隐藏
+	if hiddenForContest(con.db, c, question.Id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+		return
+	}
+
+	// 5. 返回题目详情
+	c.JSON(http.StatusOK, con.detail(&question))
+}
+
+// detail 题目详情：题面、样例，函数签名题附带各语言的初始代码
+func (con QuestionController) detail(question *models.Question) gin.H {
+	samples, err := con.loadSamples(question.Id)
+	if err != nil {
+		log.Printf("读取题目样例失败 - 题目编号: %d, 错误: %v", question.QuestionNumber, err)
+		samples = []SampleCase{}
+	}
+
+	response := gin.H{"data": question, "samples": samples}
+	if question.IsFunction() {
+		if sig, err := harness.ParseSignature(question.Signature); err == nil {
+			response["starters"] = harness.Starters(sig)
+		}
+	}
+	return response
+}
+
+// hiddenForContest 题目属于尚未开始的比赛且操作人不是管理员
+func hiddenForContest(db *gorm.DB, c *gin.Context, questionID int) bool {
+	if util.UserInstance.HasPermission(operatorUUIDFromRequest(c), "admin") {
+		return false
+	}
+	return models.InUnstartedContest(db, questionID, time.Now())
 }
 
 // ShowByQuestionID 根据题目ID查询题目详情
@@ -196,7 +285,7 @@ func (con QuestionController) ShowByQuestionID(c *gin.Context) {
 	}
 
 	var question models.Question
-	if err := models.DB.Where("question_id = ?", questionID).First(&question).Error; err != nil {
+	if err := models.DB.Where("question_id = ?", questionID).First(&question).Error; err != nil || hiddenForContest(con.db, c, question.Id) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
 		return
 	}
@@ -221,7 +310,16 @@ func (con QuestionController) Update(c *gin.Context) {
 
 	// 3. 绑定请求体到 Question 模型
 	var question models.Question
-	if err := c.ShouldBindJSON(&question); err != nil {
+	if err := c.ShouldBindBodyWith(&question, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 文件 IO 字段允许显式置空（改回标准输入/输出），需要区分“未提供”和“空字符串”
+	var ioRequest struct {
+		InputFile  *string `json:"input_file"`
+		OutputFile *string `json:"output_file"`
+	}
+	if err := c.ShouldBindBodyWith(&ioRequest, binding.JSON); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -233,11 +331,48 @@ func (con QuestionController) Update(c *gin.Context) {
 		return
 	}
 
+	// 校验状态只能由参考解校验流程写入，题型与函数签名通过 /signature 设置，子任务通过 /subtasks 设置，测试数据版本随测试用例变更递增
+	question.ValidationStatus = ""
+	question.ValidationMessage = ""
+	question.ProblemType = ""
+	question.Signature = ""
+	question.Subtasks = ""
+	question.TestDataVersion = 0
+
+	ioFiles := map[string]interface{}{}
+	inputFile, outputFile := existingQuestion.InputFile, existingQuestion.OutputFile
+	if ioRequest.InputFile != nil {
+		inputFile = *ioRequest.InputFile
+		ioFiles["input_file"] = inputFile
+	}
+	if ioRequest.OutputFile != nil {
+		outputFile = *ioRequest.OutputFile
+		ioFiles["output_file"] = outputFile
+	}
+	if msg, ok := checkIOFiles(inputFile, outputFile); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// 发布前检查参考解校验结果与测试输入
+	if question.Status == "published" && existingQuestion.Status != "published" {
+		if msg, ok := checkPublishable(models.DB, con.setter, &existingQuestion); !ok {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+	}
+
 	// 5. 更新题目（只更新请求中提供的字段，零值字段不更新）
 	if err := models.DB.Model(&existingQuestion).Updates(question).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
 		return
 	}
+	if len(ioFiles) > 0 {
+		if err := models.DB.Model(&existingQuestion).Updates(ioFiles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
+			return
+		}
+	}
 
 	// 6. 返回成功响应
 	c.JSON(http.StatusOK, gin.H{"data": existingQuestion, "msg": "题目更新成功"})
@@ -296,3 +431,16 @@ func (con QuestionController) DeleteProblem(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "题目删除成功"})
 }
+
+// checkIOFiles 校验文件 IO 的文件名，为空表示使用标准输入/输出
+func checkIOFiles(inputFile, outputFile string) (string, bool) {
+	for _, name := range []string{inputFile, outputFile} {
+		if name != "" && !services.ValidIOFileName(name) {
+			return "无效的输入/输出文件名: " + name, false
+		}
+	}
+	if inputFile != "" && inputFile == outputFile {
+		return "输入文件与输出文件不能同名", false
+	}
+	return "", true
+}
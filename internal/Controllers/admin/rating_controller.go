@@ -0,0 +1,185 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RatingController 用户 rating：计分比赛结束后更新、回滚与重算，以及 rating 历史与排行
+type RatingController struct {
+	db      *gorm.DB
+	ratings *services.RatingService
+}
+
+// NewRatingController 创建 rating 控制器，比赛名次取自 contests 的榜单
+func NewRatingController(db *gorm.DB, contests *ContestController) *RatingController {
+	return &RatingController{db: db, ratings: services.NewRatingService(db, contests.scoreboard)}
+}
+
+// ratingHistoryItem rating 历史中的一场比赛
+type ratingHistoryItem struct {
+	models.RatingChange
+	Title   string    `json:"title"`
+	EndTime time.Time `json:"end_time"`
+}
+
+// ratingChangeView 比赛 rating 变化列表项
+type ratingChangeView struct {
+	models.RatingChange
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+// userRating 用户当前 rating，未参加过计分比赛时为初始值且段位为 unrated
+func userRating(db *gorm.DB, userID string) models.UserRating {
+	r := models.UserRating{UserID: userID, Rating: models.InitialRating, MaxRating: models.InitialRating}
+	db.Where("user_id = ?", userID).Limit(1).Find(&r)
+	return r.WithTier()
+}
+
+// respondRatingError 把 rating 服务的错误转换为响应
+func respondRatingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "比赛不存在"})
+	case errors.Is(err, services.ErrContestNotRated), errors.Is(err, services.ErrRatingApplied),
+		errors.Is(err, services.ErrRatingNotApplied), errors.Is(err, services.ErrStandingsNotFinal):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// contestIDParam 解析路径中的比赛 ID
+func contestIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "比赛 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// Apply 按计分比赛的最终榜单更新 rating（管理员）
+func (rc *RatingController) Apply(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	id, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+	changes, err := rc.ratings.Apply(id)
+	if err != nil {
+		respondRatingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": changes, "msg": "rating 已更新"})
+}
+
+// Rollback 撤销比赛的 rating 变化并重算之后的比赛（管理员）
+func (rc *RatingController) Rollback(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	id, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+	if err := rc.ratings.Rollback(id); err != nil {
+		respondRatingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已撤销该比赛的 rating 变化"})
+}
+
+// Recompute 按比赛历史从头重算全部 rating（管理员）
+func (rc *RatingController) Recompute(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	count, err := rc.ratings.Recompute()
+	if err != nil {
+		respondRatingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contests": count, "msg": "rating 已重算"})
+}
+
+// ContestChanges 比赛中各选手的 rating 变化（按名次）
+func (rc *RatingController) ContestChanges(c *gin.Context) {
+	id, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+	views := []ratingChangeView{}
+	if err := rc.db.Model(&models.RatingChange{}).
+		Select("rating_changes.*, `user`.username, `user`.nickname").
+		Joins("LEFT JOIN `user` ON `user`.uuid = rating_changes.user_id").
+		Where("rating_changes.contest_id = ?", id).
+		Order("rating_changes.`rank` ASC, `user`.username ASC").
+		Scan(&views).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询 rating 变化失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// UserHistory 用户当前 rating、段位与每场计分比赛的变化
+func (rc *RatingController) UserHistory(c *gin.Context) {
+	userID := c.Param("uuid")
+	history := []ratingHistoryItem{}
+	if err := rc.db.Model(&models.RatingChange{}).
+		Select("rating_changes.*, contests.title, contests.end_time").
+		Joins("JOIN contests ON contests.id = rating_changes.contest_id").
+		Where("rating_changes.user_id = ?", userID).
+		Order("contests.end_time ASC, contests.id ASC").
+		Scan(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询 rating 历史失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rating": userRating(rc.db, userID), "history": history})
+}
+
+// Leaderboard rating 排行，支持 page/size 分页
+func (rc *RatingController) Leaderboard(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 200 {
+		size = 50
+	}
+
+	var total int64
+	rc.db.Model(&models.UserRating{}).Where("contests > 0").Count(&total)
+	type leaderboardRow struct {
+		models.UserRating
+		Username string `json:"username"`
+		Nickname string `json:"nickname"`
+	}
+	rows := []leaderboardRow{}
+	if err := rc.db.Model(&models.UserRating{}).
+		Select("user_ratings.*, `user`.username, `user`.nickname").
+		Joins("LEFT JOIN `user` ON `user`.uuid = user_ratings.user_id").
+		Where("user_ratings.contests > 0").
+		Order("user_ratings.rating DESC, user_ratings.user_id ASC").
+		Offset((page - 1) * size).Limit(size).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询 rating 排行失败"})
+		return
+	}
+	for i := range rows {
+		rows[i].UserRating = rows[i].UserRating.WithTier()
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "total": total, "page": page, "size": size})
+}
@@ -0,0 +1,260 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dachuang/internal/config"
+	"dachuang/internal/harness"
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReferenceSolutionController 参考解管理（仅管理员）
+type ReferenceSolutionController struct {
+	db     *gorm.DB
+	setter *services.ProblemSetterService
+}
+
+// NewReferenceSolutionController 创建参考解控制器
+func NewReferenceSolutionController(db *gorm.DB, ossClient *oss.OSS) *ReferenceSolutionController {
+	return &ReferenceSolutionController{db: db, setter: newProblemSetter(db, ossClient)}
+}
+
+// newProblemSetter 创建出题辅助服务（使用独立的评测服务，不更新知识图谱与能力评估）
+func newProblemSetter(db *gorm.DB, ossClient *oss.OSS) *services.ProblemSetterService {
+	bucket := config.GlobalConfig.OSS.BucketName
+	if bucket == "" {
+		bucket = "patreon-oj-cases"
+	}
+	judge := services.NewJudgeService(&config.GlobalConfig.Judge, db, ossClient, bucket, nil, nil)
+	return services.NewProblemSetterService(db, judge, ossClient, bucket)
+}
+
+// ReferenceSolutionRequest 创建/更新参考解请求
+type ReferenceSolutionRequest struct {
+	Name     string `json:"name"`
+	Language string `json:"language"` // 为空时自动识别
+	Code     string `json:"code" binding:"required"`
+	Kind     string `json:"kind"` // correct(默认)/tle/wa
+	IsMain   bool   `json:"is_main"`
+}
+
+// findQuestionByNumber 按路径参数中的题目编号查找题目
+func findQuestionByNumber(db *gorm.DB, c *gin.Context) (*models.Question, bool) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的题目编号"})
+		return nil, false
+	}
+	var question models.Question
+	if err := db.Where("question_number = ?", number).First(&question).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "题目不存在"})
+		return nil, false
+	}
+	return &question, true
+}
+
+// bindSolution 解析并校验参考解请求；函数签名题的参考解必须指定语言
+func bindSolution(c *gin.Context, question *models.Question) (*ReferenceSolutionRequest, bool) {
+	var req ReferenceSolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	req.Kind = strings.ToLower(strings.TrimSpace(req.Kind))
+	if req.Kind == "" {
+		req.Kind = models.ReferenceCorrect
+	}
+	switch req.Kind {
+	case models.ReferenceCorrect, models.ReferenceTLE, models.ReferenceWA:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind 仅支持 correct/tle/wa"})
+		return nil, false
+	}
+	if req.IsMain && req.Kind != models.ReferenceCorrect {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "主参考解必须是正确解"})
+		return nil, false
+	}
+	if question.IsFunction() && !harness.SupportsLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "函数签名题的参考解需指定 language（go/cpp/java/python）"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// saveSolution 保存参考解；设为主解时取消同题其他主解，并清除题目校验状态
+func (rc *ReferenceSolutionController) saveSolution(sol *models.ReferenceSolution) error {
+	return rc.db.Transaction(func(tx *gorm.DB) error {
+		if sol.IsMain {
+			if err := tx.Model(&models.ReferenceSolution{}).
+				Where("question_id = ? AND id <> ?", sol.QuestionID, sol.ID).
+				Update("is_main", false).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(sol).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Question{}).Where("id = ?", sol.QuestionID).
+			Updates(map[string]interface{}{"validation_status": "", "validation_message": ""}).Error
+	})
+}
+
+// Index 参考解列表
+func (rc *ReferenceSolutionController) Index(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(rc.db, c)
+	if !ok {
+		return
+	}
+
+	var solutions []models.ReferenceSolution
+	if err := rc.db.Where("question_id = ?", question.Id).Order("id ASC").Find(&solutions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询参考解失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":               solutions,
+		"validation_status":  question.ValidationStatus,
+		"validation_message": question.ValidationMessage,
+	})
+}
+
+// Store 添加参考解
+func (rc *ReferenceSolutionController) Store(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(rc.db, c)
+	if !ok {
+		return
+	}
+	req, ok := bindSolution(c, question)
+	if !ok {
+		return
+	}
+
+	sol := models.ReferenceSolution{
+		QuestionID: question.Id,
+		Name:       req.Name,
+		Language:   req.Language,
+		Code:       req.Code,
+		Kind:       req.Kind,
+		IsMain:     req.IsMain,
+	}
+	if err := rc.saveSolution(&sol); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存参考解失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": sol, "msg": "参考解添加成功"})
+}
+
+// Update 更新参考解
+func (rc *ReferenceSolutionController) Update(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(rc.db, c)
+	if !ok {
+		return
+	}
+	var sol models.ReferenceSolution
+	if err := rc.db.Where("id = ? AND question_id = ?", c.Param("id"), question.Id).First(&sol).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "参考解不存在"})
+		return
+	}
+	req, ok := bindSolution(c, question)
+	if !ok {
+		return
+	}
+
+	sol.Name = req.Name
+	sol.Language = req.Language
+	sol.Code = req.Code
+	sol.Kind = req.Kind
+	sol.IsMain = req.IsMain
+	sol.LastVerdict = ""
+	sol.LastPassed = false
+	if err := rc.saveSolution(&sol); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存参考解失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sol, "msg": "参考解更新成功"})
+}
+
+// Delete 删除参考解
+func (rc *ReferenceSolutionController) Delete(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(rc.db, c)
+	if !ok {
+		return
+	}
+	result := rc.db.Where("id = ? AND question_id = ?", c.Param("id"), question.Id).Delete(&models.ReferenceSolution{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除参考解失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "参考解不存在"})
+		return
+	}
+	if err := models.ResetValidation(question.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置校验状态失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "参考解删除成功"})
+}
+
+// Generate 用主参考解生成全部 .out 文件并校验其他参考解
+func (rc *ReferenceSolutionController) Generate(c *gin.Context) {
+	if _, ok := requireAdmin(rc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(rc.db, c)
+	if !ok {
+		return
+	}
+
+	report, err := rc.setter.GenerateAndValidate(context.Background(), question)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report, "validation_status": question.ValidationStatus})
+}
+
+// checkPublishable 发布前检查：函数签名题的签名必须有效；题目存在参考解时必须校验通过；设置了输入校验器时全部测试输入必须合法
+func checkPublishable(db *gorm.DB, setter *services.ProblemSetterService, question *models.Question) (string, bool) {
+	if question.IsFunction() {
+		if _, err := harness.ParseSignature(question.Signature); err != nil {
+			return "函数签名无效: " + err.Error(), false
+		}
+	}
+
+	var count int64
+	db.Model(&models.ReferenceSolution{}).Where("question_id = ?", question.Id).Count(&count)
+	if count > 0 && question.ValidationStatus != models.ValidationPassed {
+		return "参考解校验未通过，请先执行生成与校验", false
+	}
+
+	summary, err := setter.ValidateTestCases(context.Background(), question.Id)
+	if err != nil {
+		return "测试输入校验失败: " + err.Error(), false
+	}
+	if summary.Invalid > 0 {
+		return fmt.Sprintf("有 %d 个测试输入未通过校验", summary.Invalid), false
+	}
+	return "", true
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"net/http"
+
+	"dachuang/internal/harness"
+	"dachuang/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SignatureController 函数签名题设置（仅管理员）
+type SignatureController struct {
+	db *gorm.DB
+}
+
+// NewSignatureController 创建函数签名控制器
+func NewSignatureController(db *gorm.DB) *SignatureController {
+	return &SignatureController{db: db}
+}
+
+// Save 设置函数签名并将题目切换为函数签名题，返回各语言的初始代码
+func (sc *SignatureController) Save(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(sc.db, c)
+	if !ok {
+		return
+	}
+	var sig harness.Signature
+	if err := c.ShouldBindJSON(&sig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := sig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := sc.setProblemType(question, models.ProblemTypeFunction, sig.Marshal()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存函数签名失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sig, "starters": harness.Starters(&sig), "msg": "函数签名已保存"})
+}
+
+// Delete 删除函数签名，题目改回标准输入输出
+func (sc *SignatureController) Delete(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(sc.db, c)
+	if !ok {
+		return
+	}
+	if err := sc.setProblemType(question, models.ProblemTypeStandard, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除函数签名失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "题目已改回标准输入输出"})
+}
+
+// setProblemType 更新题型与签名；评测方式改变后参考解需重新校验
+func (sc *SignatureController) setProblemType(question *models.Question, problemType, signature string) error {
+	err := sc.db.Model(question).Updates(map[string]interface{}{"problem_type": problemType, "signature": signature}).Error
+	if err != nil {
+		return err
+	}
+	return models.ResetValidation(question.Id)
+}
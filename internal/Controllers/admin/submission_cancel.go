@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"dachuang/internal/models"
+	"dachuang/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusCancelled 提交被撤回，不计入统计
+const statusCancelled = "cancelled"
+
+// errNotCancellable 提交已结束评测
+var errNotCancellable = errors.New("提交已结束评测，无法取消")
+
+// startJudging 登记正在评测的提交，返回可被 cancelSubmission 取消的上下文
+func (sc *SubmissionController) startJudging(submissionID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	sc.runningMu.Lock()
+	sc.running[submissionID] = cancel
+	sc.runningMu.Unlock()
+	return ctx, func() {
+		sc.runningMu.Lock()
+		delete(sc.running, submissionID)
+		sc.runningMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// markCancelled 评测被中断后写入取消状态，reason 为取消原因
+func markCancelled(submission *models.Submission, reason string) {
+	submission.Status = statusCancelled
+	submission.Verdict = ""
+	submission.Results = ""
+	submission.Score = 0
+	submission.SubtaskScores = ""
+	submission.ErrorCode = ""
+	submission.ErrorMsg = reason
+	submission.NextRetryAt = nil
+}
+
+// cancelSubmission 取消排队中、等待重试或正在评测的提交
+// 排队与等待重试的提交立即取消；正在评测的提交通过 ctx 中断，由消费者写入取消状态，此时 running 为真
+func (sc *SubmissionController) cancelSubmission(submissionID, reason string) (running bool, err error) {
+	if item, err := sc.submissionQueue.Remove(submissionID); err == nil {
+		sc.dequeue()
+		return false, sc.saveCancelled(item.Submission, reason)
+	}
+
+	sc.runningMu.Lock()
+	cancel, ok := sc.running[submissionID]
+	sc.runningMu.Unlock()
+	if ok {
+		cancel(errors.New(reason))
+		return true, nil
+	}
+
+	// 等待自动重试的系统错误提交：改为取消后定时器不会再将其入队
+	var submission models.Submission
+	if err := sc.db.Where("id = ? AND status = ?", submissionID, statusSystemError).First(&submission).Error; err != nil {
+		return false, errNotCancellable
+	}
+	return false, sc.saveCancelled(&submission, reason)
+}
+
+// saveCancelled 保存取消状态并推送结束事件
+func (sc *SubmissionController) saveCancelled(submission *models.Submission, reason string) error {
+	markCancelled(submission, reason)
+	if err := sc.db.Model(&models.Submission{}).Where("id = ?", submission.ID).
+		Updates(map[string]interface{}{
+			"status": submission.Status, "verdict": "", "results": "", "score": 0, "subtask_scores": "", "error_code": "",
+			"error_msg": submission.ErrorMsg, "next_retry_at": nil,
+		}).Error; err != nil {
+		return err
+	}
+	sc.judgeService.Events.Publish(finishedEvent(submission))
+	return nil
+}
+
+// CancelSubmission 撤回排队中或正在评测的提交（提交者本人或管理员）
+func (sc *SubmissionController) CancelSubmission(c *gin.Context) {
+	op, ok := requireOperatorUUID(sc.db, c)
+	if !ok {
+		return
+	}
+	var submission models.Submission
+	if err := sc.db.Where("id = ?", c.Param("id")).First(&submission).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提交记录不存在"})
+		return
+	}
+	if !canAccessUserState(op, submission.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return
+	}
+
+	reason := "评测已被提交者取消"
+	if op != submission.UserID && util.UserInstance.HasPermission(op, "admin") {
+		reason = "评测已被管理员取消"
+	}
+	sc.respondCancel(c, submission.ID, reason)
+}
+
+// respondCancel 执行取消并返回结果；正在评测的提交返回 202，最终状态通过结果查询或 SSE 获取
+func (sc *SubmissionController) respondCancel(c *gin.Context, submissionID, reason string) {
+	running, err := sc.cancelSubmission(submissionID, reason)
+	switch {
+	case errors.Is(err, errNotCancellable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新提交状态失败"})
+	case running:
+		c.JSON(http.StatusAccepted, gin.H{"msg": "正在中断评测"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"msg": "已取消"})
+	}
+}
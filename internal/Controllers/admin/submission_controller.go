@@ -3,15 +3,19 @@ package admin
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"log"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"dachuang/internal/config"
 	"dachuang/internal/graph"
+	"dachuang/internal/harness"
+	"dachuang/internal/metrics"
 	"dachuang/internal/models"
 	"dachuang/internal/oss"
 	"dachuang/internal/services"
@@ -27,12 +31,17 @@ type SubmissionController struct {
 	db              *gorm.DB
 	judgeService    *services.JudgeService
 	graphService    *graph.QuestionGraphService
-	submissionQueue chan *models.Submission
+	rateLimiter     *services.RateLimiter
+	submissionQueue *services.SubmissionQueue
+	alerter         *services.Alerter
+
+	// running 正在评测的提交及其取消函数
+	runningMu sync.Mutex
+	running   map[string]context.CancelCauseFunc
 }
 
 // NewSubmissionController 创建提交控制器
 func NewSubmissionController(db *gorm.DB, ossClient *oss.OSS, graphService *graph.QuestionGraphService) *SubmissionController {
-	queueSize := config.GlobalConfig.Judge.QueueSize
 	bucket := config.GlobalConfig.OSS.BucketName
 	assessmentService := services.NewAssessmentService(db, graphService)
 	if bucket == "" {
@@ -42,20 +51,31 @@ func NewSubmissionController(db *gorm.DB, ossClient *oss.OSS, graphService *grap
 		db:              db,
 		judgeService:    services.NewJudgeService(&config.GlobalConfig.Judge, db, ossClient, bucket, graphService, assessmentService),
 		graphService:    graphService,
-		submissionQueue: make(chan *models.Submission, queueSize),
+		rateLimiter:     services.NewRateLimiter(config.GlobalConfig.RateLimit, db),
+		submissionQueue: services.NewSubmissionQueue(time.Duration(config.GlobalConfig.Judge.QueueAging) * time.Second),
+		alerter:         services.NewAlerter(config.GlobalConfig.Judge.Retry.AlertWebhook),
+		running:         make(map[string]context.CancelCauseFunc),
 	}
 
 	// 启动消费者协程
 	go controller.consumeSubmissions()
+	go controller.resumeRetries()
 
 	return controller
 }
 
-// SubmitRequest 提交代码请求结构体
+// permRateLimitExempt 拥有该权限的用户不受提交频率限制（管理员可通过用户权限配置授予）
+const permRateLimitExempt = "rate_limit_exempt"
+
+// SubmitRequest 提交代码请求结构体，code 与 files 二选一
 type SubmitRequest struct {
-	UserID         string `json:"user_id" binding:"required"`
-	QuestionNumber int    `json:"question_number" binding:"required"`
-	Code           string `json:"code" binding:"required"`
+	UserID         string                `json:"user_id" binding:"required"`
+	QuestionNumber int                   `json:"question_number" binding:"required"`
+	Code           string                `json:"code"`
+	Files          []services.SourceFile `json:"files"`      // 多文件提交
+	Language       string                `json:"language"`   // 多文件提交或函数签名题的语言，多文件为空时按扩展名识别
+	Mode           string                `json:"mode"`       // 评测模式：full(默认)/sample(仅样例)
+	ContestID      uint                  `json:"contest_id"` // 比赛提交所属的比赛，为空表示练习提交
 }
 
 // submissionListItem 提交记录列表项
@@ -69,25 +89,12 @@ type submissionListItem struct {
 	MemoryKB       int64     `json:"memory_kb"`
 	Language       string    `json:"language"`
 	CodeLength     int       `json:"code_length"`
-}
+	JudgeMode      string    `json:"judge_mode"`
+	Frozen         bool      `json:"frozen,omitempty"` // 封榜期间他人封榜后的比赛提交，不返回状态与运行数据
 
-// getErrorCode 根据错误信息返回对应的错误码
-func getErrorCode(err error) string {
-	errMsg := err.Error()
-	switch {
-	case strings.Contains(errMsg, "测试用例"):
-		return "E001" // 测试用例相关错误
-	case strings.Contains(errMsg, "编译"):
-		return "E002" // 编译错误
-	case strings.Contains(errMsg, "超时"):
-		return "E003" // 运行超时
-	case strings.Contains(errMsg, "内存"):
-		return "E004" // 内存超限
-	case strings.Contains(errMsg, "网络"):
-		return "E005" // 网络错误
-	default:
-		return "E999" // 未知错误
-	}
+	ContestID  uint `json:"-"`
+	QuestionID int  `json:"-"`
+	Virtual    bool `json:"-"`
 }
 
 // getErrorMessage 根据错误码返回用户友好的错误信息
@@ -105,6 +112,10 @@ func getErrorMessage(errorCode string) string {
 		return "网络连接错误，请稍后重试"
 	case "E999":
 		return "系统内部错误，请联系管理员"
+	case models.VerdictCompileError:
+		return "提交无法评测，请检查语言与提交格式"
+	case "SE":
+		return "评测系统暂时故障，将自动重新评测"
 	default:
 		return "未知错误，请联系管理员"
 	}
@@ -124,31 +135,59 @@ func parseResults(resultsStr string) []models.TestCaseResult {
 	return results
 }
 
+// redactHiddenResults 隐藏测试用例的 stderr 仅管理员可见
+func redactHiddenResults(results []models.TestCaseResult, isAdmin bool) []models.TestCaseResult {
+	if isAdmin {
+		return results
+	}
+	for i := range results {
+		if results[i].IsHidden {
+			results[i].Stderr = ""
+		}
+	}
+	return results
+}
+
 // consumeSubmissions 消费者函数，处理消息队列中的提交信息
 func (sc *SubmissionController) consumeSubmissions() {
-	for submission := range sc.submissionQueue {
+	for {
+		item := sc.submissionQueue.Pop()
+		submission := item.Submission
+		ctx, done := sc.startJudging(submission.ID) // 评测期间可被 cancelSubmission 中断
+		sc.dequeue()
+		dequeuedAt := time.Now()
+		metrics.QueueWait.WithLabelValues(services.PriorityNames[item.Priority]).Observe(dequeuedAt.Sub(item.EnqueuedAt).Seconds())
+		timeline := services.NewTimeline()
+		timeline.Add(services.TimelineQueue, item.EnqueuedAt, dequeuedAt)
+
 		// 1. 更新提交状态为处理中
 		submission.Status = "processing"
 		if err := sc.db.Save(submission).Error; err != nil {
 			log.Printf("保存提交状态失败 - 提交ID: %s, 错误: %v", submission.ID, err)
+			done()
 			continue
 		}
 
 		// 2. 调用评测服务
-		if err := sc.judgeService.JudgeCode(submission); err != nil {
+		err := sc.judgeService.JudgeCode(ctx, submission, timeline)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			log.Printf("评测已取消 - 提交ID: %s", submission.ID)
+			markCancelled(submission, context.Cause(ctx).Error())
+		case err != nil:
 			log.Printf("评测失败 - 提交ID: %s, 错误: %v", submission.ID, err)
-			submission.Status = "error"
-
-			// 设置错误码和错误信息
-			submission.ErrorCode = getErrorCode(err)
-			submission.ErrorMsg = err.Error()
-			submission.Results = "" // 清空结果
+			sc.handleJudgeError(submission, err)
 		}
+		done()
 
 		// 3. 保存评测结果
 		if err := sc.db.Save(submission).Error; err != nil {
 			log.Printf("保存评测结果失败 - 提交ID: %s, 错误: %v", submission.ID, err)
 		}
+		sc.judgeService.Events.Publish(resultEvent(submission))
+		if submission.NextRetryAt != nil {
+			sc.scheduleRetry(submission.ID, *submission.NextRetryAt, item.Priority)
+		}
 
 		// 判断是否AC
 		allcurrent := true
@@ -172,6 +211,11 @@ func (sc *SubmissionController) consumeSubmissions() {
 			continue
 		}
 
+		// 样例评测不计入解题记录
+		if submission.JudgeMode == models.JudgeModeSample {
+			continue
+		}
+
 		// 4. 更新用户解题列表
 		if question.Id == 0 {
 			_ = sc.db.Where("id = ?", submission.QuestionID).First(&question)
@@ -200,7 +244,32 @@ func (sc *SubmissionController) consumeSubmissions() {
 	}
 }
 
-// SubmitCode 提交代码
+// RejudgeAccepted 测试数据新增后重新评测题目下已通过的完整评测提交，返回加入队列的数量
+func (sc *SubmissionController) RejudgeAccepted(questionID int) (int, error) {
+	var submissions []*models.Submission
+	if err := sc.db.Where("question_id = ? AND verdict = ? AND judge_mode = ?", questionID, models.VerdictAccepted, models.JudgeModeFull).
+		Order("created_at ASC").Find(&submissions).Error; err != nil {
+		return 0, err
+	}
+	for _, submission := range submissions {
+		submission.Status = "pending"
+		submission.Verdict = ""
+		submission.Results = ""
+		submission.ErrorCode = ""
+		submission.ErrorMsg = ""
+		if err := sc.db.Save(submission).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	// 重新评测使用最低优先级，不受队列深度限制
+	for _, submission := range submissions {
+		sc.enqueue(submission, services.PriorityRejudge)
+	}
+	return len(submissions), nil
+}
+
+// SubmitCode 提交代码（单文件 code 或多文件 files）
 func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 	var submitRequest SubmitRequest
 
@@ -208,7 +277,81 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if len(submitRequest.Files) == 0 {
+		if submitRequest.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code 或 files 不能为空"})
+			return
+		}
+		sc.submit(c, submitRequest, nil)
+		return
+	}
+
+	files, err := services.SanitizeSourceFiles(submitRequest.Files, services.SourceLimitsFromConfig(config.GlobalConfig.Judge.MultiFile))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sc.submit(c, submitRequest, files)
+}
 
+// SubmitArchive 以压缩包（zip / tar / tar.gz）提交多文件项目，表单字段同 SubmitRequest，文件字段为 archive
+func (sc *SubmissionController) SubmitArchive(c *gin.Context) {
+	number, err := strconv.Atoi(strings.TrimSpace(c.PostForm("question_number")))
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "question_number 无效"})
+		return
+	}
+	submitRequest := SubmitRequest{
+		UserID:         strings.TrimSpace(c.PostForm("user_id")),
+		QuestionNumber: number,
+		Language:       strings.TrimSpace(c.PostForm("language")),
+		Mode:           c.PostForm("mode"),
+	}
+	if v := strings.TrimSpace(c.PostForm("contest_id")); v != "" {
+		contestID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "contest_id 无效"})
+			return
+		}
+		submitRequest.ContestID = uint(contestID)
+	}
+	if submitRequest.UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id 不能为空"})
+		return
+	}
+
+	header, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少压缩包文件 archive"})
+		return
+	}
+	limits := services.SourceLimitsFromConfig(config.GlobalConfig.Judge.MultiFile)
+	if header.Size > limits.MaxTotalSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "压缩包过大"})
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取压缩包失败"})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limits.MaxTotalSize+1))
+	if err != nil || int64(len(data)) > limits.MaxTotalSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取压缩包失败或压缩包过大"})
+		return
+	}
+
+	files, err := services.ExtractArchive(header.Filename, data, limits)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sc.submit(c, submitRequest, files)
+}
+
+// submit 校验用户、题目与频率限制后创建提交并加入评测队列；files 非空时为多文件提交
+func (sc *SubmissionController) submit(c *gin.Context, submitRequest SubmitRequest, files []services.SourceFile) {
 	// 验证用户是否存在
 	var user models.User
 	if err := sc.db.Where("uuid = ?", submitRequest.UserID).First(&user).Error; err != nil {
@@ -227,13 +370,108 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 		return
 	}
 
+	mode := strings.TrimSpace(submitRequest.Mode)
+	if mode == "" {
+		mode = models.JudgeModeFull
+	}
+	if mode != models.JudgeModeFull && mode != models.JudgeModeSample {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 仅支持 full/sample"})
+		return
+	}
+
+	// 比赛提交需在比赛时间内且题目属于该比赛；未开始比赛中的题目不接受练习提交，进行中的 oi 比赛的题目也不接受，以免绕过结果隐藏
+	// oi 赛制比赛中只评测样例，结束后由管理员对每题最后一次提交发起完整评测；虚拟参赛直接完整评测，结果在虚拟比赛结束后公布
+	isAdmin := util.UserInstance.HasPermission(user.UUID, "admin")
+	virtual := false
+	if submitRequest.ContestID != 0 {
+		contest, isVirtual, status, msg := checkContestSubmission(sc.db, submitRequest.ContestID, user.UUID, question.Id, isAdmin)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		virtual = isVirtual
+		if virtual {
+			mode = models.JudgeModeFull
+		} else if contest.Rule == models.ContestRuleOI {
+			mode = models.JudgeModeSample
+		}
+	} else if !isAdmin && models.InUnstartedContest(sc.db, question.Id, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "题目尚未公开"})
+		return
+	} else if !isAdmin && models.InRunningOIContest(sc.db, question.Id, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "题目所在的 oi 比赛进行中，请在比赛中提交"})
+		return
+	}
+
+	if !util.UserInstance.HasPermission(user.UUID, permRateLimitExempt) {
+		allowed, wait := sc.rateLimiter.AllowSubmission(services.RateLimitRequest{
+			UserID:     user.UUID,
+			IP:         c.ClientIP(),
+			QuestionID: question.Id,
+			Contest:    submitRequest.ContestID != 0 && !virtual,
+		})
+		if !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "提交过于频繁，请稍后再试", "retry_after": retryAfter})
+			return
+		}
+	}
+
 	lang := ""
-	if sc.judgeService != nil {
+	if question.IsFunction() {
+		// 函数签名题的代码只有函数实现，无法可靠识别语言，需由提交方指定
+		lang = strings.TrimSpace(submitRequest.Language)
+		if files != nil || !harness.SupportsLanguage(lang) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "函数签名题需提交单个文件并指定 language（go/cpp/java/python）"})
+			return
+		}
+	} else if files != nil {
+		lang = strings.TrimSpace(submitRequest.Language)
+		if lang == "" {
+			lang = services.DetectProjectLanguage(files)
+		}
+		if err := sc.judgeService.CheckProject(lang, files); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if sc.judgeService.OSSClient == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "对象存储未配置，暂不支持多文件提交"})
+			return
+		}
+	} else if sc.judgeService != nil {
 		lang = sc.judgeService.DetectLanguage(submitRequest.Code)
 	}
 
 	// 创建提交记录，使用题目的数据库ID
 	submission := models.NewSubmission(submitRequest.UserID, question.Id, submitRequest.Code, lang)
+	if files != nil {
+		// 多文件提交的源码存放在 OSS，不写入 code 列
+		prefix, err := services.StoreSubmissionFiles(c.Request.Context(), sc.judgeService.OSSClient, sc.judgeService.OSSBucket, submission.ID, files)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		submission.SourceKey = prefix
+		submission.FileCount = len(files)
+		submission.CodeLength = 0
+		for _, f := range files {
+			submission.CodeLength += len(f.Content)
+		}
+	}
+	if mode == models.JudgeModeSample {
+		// 样例评测仅供自测，不公开也不计入统计
+		submission.JudgeMode = models.JudgeModeSample
+		submission.IsPublic = false
+	}
+	submission.ContestID = submitRequest.ContestID
+	submission.Virtual = virtual
+
+	priority := submissionPriority(submission)
+	if limit := config.GlobalConfig.Judge.QueueSize; limit > 0 && sc.submissionQueue.Len() >= limit {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "评测队列已满，请稍后再试"})
+		return
+	}
 
 	if err := sc.db.Create(submission).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交创建失败"})
@@ -241,7 +479,7 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 	}
 
 	// 将提交加入评测队列
-	sc.submissionQueue <- submission
+	sc.enqueue(submission, priority)
 
 	c.JSON(http.StatusOK, gin.H{
 		"submission_id":   submission.ID,
@@ -249,6 +487,11 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 		"question_number": submitRequest.QuestionNumber, // 返回题目编号
 		"question_id":     submission.QuestionID,        // 返回内部ID
 		"status":          submission.Status,
+		"judge_mode":      submission.JudgeMode,
+		"language":        submission.Language,
+		"file_count":      submission.FileCount,
+		"contest_id":      submission.ContestID,
+		"virtual":         submission.Virtual,
 		"message":         "代码已提交，正在评测中",
 		"created_at":      submission.CreatedAt,
 	})
@@ -271,6 +514,8 @@ func (sc *SubmissionController) GetSubmissionResult(c *gin.Context) {
 		"user_id":       submission.UserID,
 		"question_id":   submission.QuestionID,
 		"status":        submission.Status,
+		"judge_mode":    submission.JudgeMode,
+		"file_count":    submission.FileCount,
 		"created_at":    submission.CreatedAt,
 		"updated_at":    submission.UpdatedAt,
 	}
@@ -281,11 +526,34 @@ func (sc *SubmissionController) GetSubmissionResult(c *gin.Context) {
 		response["question_number"] = question.QuestionNumber
 	}
 
+	// 封榜期间他人封榜后的提交不公布结果；oi 赛制最终评测前（虚拟参赛为虚拟比赛结束前）选手只能看到是否编译通过
+	op := operatorUUIDFromRequest(c)
+	isAdmin := util.UserInstance.HasPermission(op, "admin")
+	if submission.ContestID != 0 && !isAdmin {
+		var contest models.Contest
+		if err := sc.db.Where("id = ?", submission.ContestID).First(&contest).Error; err == nil {
+			if frozenForViewer(sc.db, &contest, &submission, op) {
+				delete(response, "status")
+				response["frozen"] = true
+				response["results"] = []models.TestCaseResult{}
+				response["message"] = "封榜期间不公布其他选手的评测结果"
+				c.JSON(http.StatusOK, response)
+				return
+			}
+			if submission.Status == "completed" && submission.Verdict != models.VerdictCompileError && contestResultsHidden(sc.db, &contest, &submission) {
+				response["results"] = []models.TestCaseResult{}
+				response["message"] = "编译通过，评测结果将在比赛结束后公布"
+				c.JSON(http.StatusOK, response)
+				return
+			}
+		}
+	}
+
 	// 根据评测状态返回不同的数据
 	switch submission.Status {
 	case "completed":
 		// 评测完成：解析结果并计算通过率
-		results := parseResults(submission.Results)
+		results := redactHiddenResults(parseResults(submission.Results), isAdmin)
 		if len(results) > 0 {
 			passCount := 0
 			for _, result := range results {
@@ -294,9 +562,17 @@ func (sc *SubmissionController) GetSubmissionResult(c *gin.Context) {
 				}
 			}
 			response["results"] = results
+			response["verdict"] = submission.Verdict
 			response["pass_rate"] = float64(passCount) / float64(len(results))
 			response["total_cases"] = len(results)
 			response["passed_cases"] = passCount
+			if submission.JudgeMode == models.JudgeModeFull {
+				response["score"] = submission.Score
+				response["subtask_scores"] = services.ParseSubtaskScores(submission.SubtaskScores)
+			}
+			if submission.CachedFrom != "" {
+				response["cached_from"] = submission.CachedFrom // 结果复用自相同代码的提交，未重新运行
+			}
 		} else {
 			// 评测完成但无结果（异常情况）
 			response["results"] = []models.TestCaseResult{}
@@ -315,15 +591,61 @@ func (sc *SubmissionController) GetSubmissionResult(c *gin.Context) {
 		response["error_code"] = submission.ErrorCode
 		response["error_message"] = submission.ErrorMsg
 		response["message"] = getErrorMessage(submission.ErrorCode)
+	case statusSystemError:
+		// 系统错误：故障详情仅管理员可见
+		response["results"] = []models.TestCaseResult{}
+		response["error_code"] = submission.ErrorCode
+		response["next_retry_at"] = submission.NextRetryAt
+		response["message"] = getErrorMessage(submission.ErrorCode)
+		if submission.NextRetryAt == nil {
+			response["message"] = "评测系统故障，管理员处理后将重新评测"
+		}
+		if util.UserInstance.HasPermission(operatorUUIDFromRequest(c), "admin") {
+			response["error_message"] = submission.ErrorMsg
+			response["attempts"] = submission.Attempts
+		}
 	default:
 		// 等待评测：返回等待状态
 		response["results"] = []models.TestCaseResult{}
 		response["message"] = "代码已提交，等待评测"
 	}
 
+	// 评测各阶段耗时仅管理员可见
+	if submission.Timeline != "" && util.UserInstance.HasPermission(operatorUUIDFromRequest(c), "admin") {
+		response["timeline"] = services.ParseTimeline(submission.Timeline)
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// GetSubmissionFiles 获取多文件提交的源文件（仅提交者本人或管理员）
+func (sc *SubmissionController) GetSubmissionFiles(c *gin.Context) {
+	op, ok := requireOperatorUUID(sc.db, c)
+	if !ok {
+		return
+	}
+	var submission models.Submission
+	if err := sc.db.Where("id = ?", c.Param("id")).First(&submission).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提交记录不存在"})
+		return
+	}
+	if !canAccessUserState(op, submission.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return
+	}
+	if submission.FileCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该提交不是多文件提交"})
+		return
+	}
+
+	files, err := services.LoadSubmissionFiles(c.Request.Context(), sc.judgeService.OSSClient, sc.judgeService.OSSBucket, submission.SourceKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": files, "language": submission.Language})
+}
+
 // ListProblemSubmissions 获取题目提交记录（公开）
 func (sc *SubmissionController) ListProblemSubmissions(c *gin.Context) {
 	qn, err := strconv.Atoi(strings.TrimSpace(c.Param("question_number")))
@@ -353,7 +675,8 @@ func (sc *SubmissionController) ListProblemSubmissions(c *gin.Context) {
 	status := strings.TrimSpace(c.Query("status"))
 	language := strings.TrimSpace(c.Query("language"))
 
-	countQ := sc.db.Table("submissions").Joins("JOIN question ON question.id = submissions.question_id").Where("submissions.question_id = ?", question.Id)
+	countQ := sc.db.Table("submissions").Joins("JOIN question ON question.id = submissions.question_id").
+		Where("submissions.question_id = ? AND submissions.judge_mode = ? AND submissions.status <> ?", question.Id, models.JudgeModeFull, statusCancelled)
 	if status != "" {
 		countQ = countQ.Where("submissions.status = ?", status)
 	}
@@ -369,9 +692,9 @@ func (sc *SubmissionController) ListProblemSubmissions(c *gin.Context) {
 
 	items := make([]submissionListItem, 0, size)
 	listQ := sc.db.Table("submissions").
-		Select("submissions.id AS submission_id, submissions.user_id, question.question_number, submissions.created_at AS submitted_at, submissions.status, submissions.runtime_ms, submissions.memory_kb, submissions.language, submissions.code_length").
+		Select("submissions.id AS submission_id, submissions.user_id, question.question_number, submissions.created_at AS submitted_at, submissions.status, submissions.runtime_ms, submissions.memory_kb, submissions.language, submissions.code_length, submissions.judge_mode, submissions.contest_id, submissions.question_id, submissions.virtual").
 		Joins("JOIN question ON question.id = submissions.question_id").
-		Where("submissions.question_id = ?", question.Id)
+		Where("submissions.question_id = ? AND submissions.judge_mode = ? AND submissions.status <> ?", question.Id, models.JudgeModeFull, statusCancelled)
 	if status != "" {
 		listQ = listQ.Where("submissions.status = ?", status)
 	}
@@ -382,6 +705,7 @@ func (sc *SubmissionController) ListProblemSubmissions(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
+	sc.hideFrozenItems(items, operatorUUIDFromRequest(c))
 
 	pages := int64(0)
 	if total > 0 {
@@ -390,6 +714,38 @@ func (sc *SubmissionController) ListProblemSubmissions(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"total": total, "page": page, "size": size, "pages": pages, "items": items})
 }
 
+// hideFrozenItems 封榜期间对 viewer 隐藏他人封榜后比赛提交的状态与运行数据
+func (sc *SubmissionController) hideFrozenItems(items []submissionListItem, viewer string) {
+	if util.UserInstance.HasPermission(viewer, "admin") {
+		return
+	}
+	contests := make(map[uint]*models.Contest)
+	for i := range items {
+		item := &items[i]
+		if item.ContestID == 0 {
+			continue
+		}
+		contest, ok := contests[item.ContestID]
+		if !ok {
+			contest = &models.Contest{}
+			if err := sc.db.Where("id = ?", item.ContestID).First(contest).Error; err != nil {
+				contest = nil
+			}
+			contests[item.ContestID] = contest
+		}
+		if contest == nil {
+			continue
+		}
+		submission := models.Submission{UserID: item.UserID, QuestionID: item.QuestionID, ContestID: item.ContestID, Virtual: item.Virtual, CreatedAt: item.SubmittedAt}
+		if frozenForViewer(sc.db, contest, &submission, viewer) {
+			item.Frozen = true
+			item.Status = ""
+			item.RuntimeMs = 0
+			item.MemoryKB = 0
+		}
+	}
+}
+
 // ListUserSubmissions 获取用户提交记录
 func (sc *SubmissionController) ListUserSubmissions(c *gin.Context) {
 	opUUID, ok := requireOperatorUUID(sc.db, c)
@@ -457,7 +813,7 @@ func (sc *SubmissionController) ListUserSubmissions(c *gin.Context) {
 
 	items := make([]submissionListItem, 0, size)
 	listQ := sc.db.Table("submissions").
-		Select("submissions.id AS submission_id, submissions.user_id, question.question_number, submissions.created_at AS submitted_at, submissions.status, submissions.runtime_ms, submissions.memory_kb, submissions.language, submissions.code_length").
+		Select("submissions.id AS submission_id, submissions.user_id, question.question_number, submissions.created_at AS submitted_at, submissions.status, submissions.runtime_ms, submissions.memory_kb, submissions.language, submissions.code_length, submissions.judge_mode").
 		Joins("JOIN question ON question.id = submissions.question_id").
 		Where("submissions.user_id = ?", targetUUID)
 	if questionID != nil {
@@ -473,6 +829,7 @@ func (sc *SubmissionController) ListUserSubmissions(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
+	sc.hideFrozenItems(items, operatorUUIDFromRequest(c))
 
 	pages := int64(0)
 	if total > 0 {
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"net/http"
+
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueMoveRequest 调整排队提交的请求
+type QueueMoveRequest struct {
+	Priority string `json:"priority"` // contest/practice/custom/rejudge，为空时保持不变
+	ToFront  bool   `json:"to_front"` // 排到所在优先级的最前面
+}
+
+// ListQueue 按优先级分组查看评测队列（管理员）
+func (sc *SubmissionController) ListQueue(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	entries := sc.submissionQueue.Snapshot()
+	groups := make(map[string][]services.QueueEntry, len(services.PriorityNames))
+	for _, name := range services.PriorityNames {
+		groups[name] = []services.QueueEntry{}
+	}
+	for _, entry := range entries {
+		groups[entry.EffectivePriority] = append(groups[entry.EffectivePriority], entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": groups, "total": len(entries)})
+}
+
+// MoveQueued 调整排队提交的优先级或将其提到同级队首（管理员）
+func (sc *SubmissionController) MoveQueued(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	var req QueueMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	priority := -1
+	if req.Priority != "" {
+		p, ok := services.ParsePriority(req.Priority)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority 仅支持 contest/practice/custom/rejudge"})
+			return
+		}
+		priority = p
+	}
+
+	if err := sc.submissionQueue.Move(id, priority, req.ToFront); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	sc.publishPositions()
+	c.JSON(http.StatusOK, gin.H{"msg": "已调整", "position": sc.submissionQueue.Position(id)})
+}
+
+// CancelQueued 取消排队或正在评测的提交（管理员）
+func (sc *SubmissionController) CancelQueued(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	sc.respondCancel(c, c.Param("id"), "评测已被管理员取消")
+}
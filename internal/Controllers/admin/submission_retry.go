@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dachuang/internal/config"
+	"dachuang/internal/metrics"
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusSystemError 评测系统故障，等待自动重试或管理员处理
+const statusSystemError = "system_error"
+
+// handleJudgeError 区分评测失败的原因：提交本身的问题按编译错误展示，系统错误安排退避重试
+func (sc *SubmissionController) handleJudgeError(submission *models.Submission, err error) {
+	submission.Results = ""
+	submission.ErrorMsg = err.Error()
+
+	kind, system := services.ClassifyJudgeError(err)
+	if !system {
+		submission.Status = "error"
+		submission.ErrorCode = models.VerdictCompileError
+		return
+	}
+
+	metrics.SystemErrors.WithLabelValues(kind).Inc()
+	submission.Status = statusSystemError
+	submission.ErrorCode = "SE"
+	submission.Attempts++
+	submission.NextRetryAt = nil
+
+	retry := config.GlobalConfig.Judge.Retry
+	if submission.Attempts < retry.MaxAttempts {
+		next := time.Now().Add(retryDelay(retry, submission.Attempts))
+		submission.NextRetryAt = &next
+		return
+	}
+	sc.alerter.Notify(services.Alert{
+		Event:        "judge_system_error",
+		Kind:         kind,
+		SubmissionID: submission.ID,
+		Attempts:     submission.Attempts,
+		Error:        err.Error(),
+		Text:         fmt.Sprintf("提交 %s 评测系统错误（%s），已尝试 %d 次仍失败: %v", submission.ID, kind, submission.Attempts, err),
+	})
+}
+
+// retryDelay 第 attempts 次失败后的重试延迟：base_delay 起翻倍，不超过 max_delay
+func retryDelay(retry config.RetryConfig, attempts int) time.Duration {
+	delay := time.Duration(retry.BaseDelay) * time.Second
+	if delay <= 0 {
+		delay = time.Second
+	}
+	limit := time.Duration(retry.MaxDelay) * time.Second
+	for i := 1; i < attempts && (limit <= 0 || delay < limit); i++ {
+		delay *= 2
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// submissionPriority 提交的排队优先级：比赛提交优先，样例评测按自定义测试排队，虚拟参赛按练习提交排队
+func submissionPriority(submission *models.Submission) int {
+	switch {
+	case submission.ContestID != 0 && !submission.Virtual:
+		return services.PriorityContest
+	case submission.JudgeMode == models.JudgeModeSample:
+		return services.PriorityCustom
+	default:
+		return services.PriorityPractice
+	}
+}
+
+// scheduleRetry 到达 NextRetryAt 后将提交重新加入队列
+func (sc *SubmissionController) scheduleRetry(submissionID string, at time.Time, priority int) {
+	time.AfterFunc(time.Until(at), func() {
+		sc.requeue(submissionID, priority)
+	})
+}
+
+// requeue 将仍处于系统错误状态的提交重新加入队列；已被管理员处理的提交不再入队
+func (sc *SubmissionController) requeue(submissionID string, priority int) bool {
+	var submission models.Submission
+	result := sc.db.Model(&models.Submission{}).Where("id = ? AND status = ?", submissionID, statusSystemError).
+		Updates(map[string]interface{}{"status": "pending", "next_retry_at": nil})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	if err := sc.db.Where("id = ?", submissionID).First(&submission).Error; err != nil {
+		log.Printf("重试评测时查询提交失败 - 提交ID: %s, 错误: %v", submissionID, err)
+		return false
+	}
+	sc.enqueue(&submission, priority)
+	return true
+}
+
+// resumeRetries 服务重启后恢复尚未执行的自动重试
+func (sc *SubmissionController) resumeRetries() {
+	var submissions []models.Submission
+	if err := sc.db.Select("id", "next_retry_at", "contest_id", "virtual", "judge_mode").
+		Where("status = ? AND next_retry_at IS NOT NULL", statusSystemError).Find(&submissions).Error; err != nil {
+		log.Printf("恢复自动重试失败: %v", err)
+		return
+	}
+	for _, s := range submissions {
+		sc.scheduleRetry(s.ID, *s.NextRetryAt, submissionPriority(&s))
+	}
+}
+
+// systemErrorItem 系统错误提交列表项
+type systemErrorItem struct {
+	SubmissionID string     `json:"submission_id"`
+	UserID       string     `json:"user_id"`
+	QuestionID   int        `json:"question_id"`
+	Language     string     `json:"language"`
+	JudgeMode    string     `json:"judge_mode"`
+	Attempts     int        `json:"attempts"`
+	NextRetryAt  *time.Time `json:"next_retry_at"`
+	ErrorMsg     string     `json:"error_msg"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ListSystemErrors 系统错误状态的提交列表（管理员），stuck=true 时只返回已停止自动重试的提交
+func (sc *SubmissionController) ListSystemErrors(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	q := sc.db.Model(&models.Submission{}).Where("status = ?", statusSystemError)
+	if c.Query("stuck") == "true" {
+		// 重启后未恢复或定时器丢失的提交也算卡住
+		q = q.Where("next_retry_at IS NULL OR next_retry_at < ?", time.Now().Add(-time.Minute))
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	items := make([]systemErrorItem, 0, size)
+	if err := q.Select("id AS submission_id, user_id, question_id, language, judge_mode, attempts, next_retry_at, error_msg, created_at, updated_at").
+		Order("updated_at DESC").Limit(size).Offset(size * (page - 1)).Scan(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "total": total, "page": page, "size": size})
+}
+
+// RetrySubmission 立即重新评测系统错误状态的提交（管理员），重置重试次数
+func (sc *SubmissionController) RetrySubmission(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	id := c.Param("id")
+	var submission models.Submission
+	if err := sc.db.Select("id", "contest_id", "virtual", "judge_mode").
+		Where("id = ? AND status = ?", id, statusSystemError).First(&submission).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提交不存在或不处于系统错误状态"})
+		return
+	}
+	if err := sc.db.Model(&models.Submission{}).Where("id = ? AND status = ?", id, statusSystemError).
+		Update("attempts", 0).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新提交失败"})
+		return
+	}
+	if !sc.requeue(id, submissionPriority(&submission)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提交不存在或不处于系统错误状态"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已重新加入评测队列"})
+}
@@ -0,0 +1,252 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"dachuang/internal/metrics"
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+	"dachuang/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeat SSE 心跳间隔，防止代理因连接空闲而断开
+const sseHeartbeat = 15 * time.Second
+
+// enqueue 将提交按优先级加入评测队列并推送排队位置
+func (sc *SubmissionController) enqueue(submission *models.Submission, priority int) {
+	position := sc.submissionQueue.Push(submission, priority)
+	metrics.QueueDepth.Inc()
+
+	sc.judgeService.Events.Publish(services.JudgeEvent{
+		Type:         services.EventQueued,
+		SubmissionID: submission.ID,
+		UserID:       submission.UserID,
+		ContestID:    submission.ContestID,
+		Position:     position,
+		Status:       submission.Status,
+	})
+}
+
+// dequeue 提交开始评测或被取消后，向仍在排队的提交推送新位置
+func (sc *SubmissionController) dequeue() {
+	metrics.QueueDepth.Dec()
+	sc.publishPositions()
+}
+
+// publishPositions 按当前评测顺序推送全部排队提交的位置
+func (sc *SubmissionController) publishPositions() {
+	for _, entry := range sc.submissionQueue.Snapshot() {
+		sc.judgeService.Events.Publish(services.JudgeEvent{
+			Type:         services.EventQueued,
+			SubmissionID: entry.SubmissionID,
+			UserID:       entry.UserID,
+			Position:     entry.Position,
+			Status:       "pending",
+		})
+	}
+}
+
+// finishedEvent 构造评测结束事件
+func finishedEvent(submission *models.Submission) services.JudgeEvent {
+	return services.JudgeEvent{
+		Type:         services.EventFinished,
+		SubmissionID: submission.ID,
+		UserID:       submission.UserID,
+		ContestID:    submission.ContestID,
+		Status:       submission.Status,
+		Verdict:      submission.Verdict,
+	}
+}
+
+// resultEvent 评测一轮结束后的事件：等待自动重试的系统错误推送 retry，其余推送结束事件
+func resultEvent(submission *models.Submission) services.JudgeEvent {
+	if submission.Status == statusSystemError && submission.NextRetryAt != nil {
+		return services.JudgeEvent{
+			Type:         services.EventRetry,
+			SubmissionID: submission.ID,
+			UserID:       submission.UserID,
+			ContestID:    submission.ContestID,
+			Status:       submission.Status,
+		}
+	}
+	return finishedEvent(submission)
+}
+
+// snapshotEvent 根据数据库中的当前状态构造一条事件，作为订阅后的第一条消息
+func (sc *SubmissionController) snapshotEvent(submission *models.Submission) services.JudgeEvent {
+	switch submission.Status {
+	case "completed", "error", statusCancelled, statusSystemError:
+		return resultEvent(submission)
+	case "processing":
+		return services.JudgeEvent{
+			Type:         services.EventStage,
+			SubmissionID: submission.ID,
+			UserID:       submission.UserID,
+			Status:       submission.Status,
+		}
+	default:
+		return services.JudgeEvent{
+			Type:         services.EventQueued,
+			SubmissionID: submission.ID,
+			UserID:       submission.UserID,
+			Position:     sc.submissionQueue.Position(submission.ID),
+			Status:       submission.Status,
+		}
+	}
+}
+
+// prepareSSE 设置 SSE 响应头
+func prepareSSE(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+}
+
+// writeEvent 写出一条 SSE 事件并立即刷新
+func writeEvent(c *gin.Context, ev services.JudgeEvent) {
+	c.SSEvent(ev.Type, ev)
+	c.Writer.Flush()
+}
+
+// resultRedactor 返回去掉隐藏结论的事件过滤函数：比赛结果对选手隐藏时（见 contestResultsHidden），
+// 测试点与结束事件只保留编译错误结论，与 GetSubmissionResult 一致；管理员不过滤
+func (sc *SubmissionController) resultRedactor(isAdmin bool) func(services.JudgeEvent) services.JudgeEvent {
+	if isAdmin {
+		return nil
+	}
+	submissions := make(map[string]*models.Submission) // 提交所属比赛不会变化，按 ID 缓存
+	return func(ev services.JudgeEvent) services.JudgeEvent {
+		if ev.Verdict == "" || ev.Verdict == models.VerdictCompileError {
+			return ev
+		}
+		submission, ok := submissions[ev.SubmissionID]
+		if !ok {
+			submission = &models.Submission{}
+			if err := sc.db.Select("id", "user_id", "contest_id", "virtual").Where("id = ?", ev.SubmissionID).First(submission).Error; err != nil {
+				submission = nil
+			}
+			submissions[ev.SubmissionID] = submission
+		}
+		if submission == nil || submission.ContestID == 0 {
+			return ev
+		}
+		var contest models.Contest
+		if err := sc.db.Select("id", "rule", "final_judged_at").Where("id = ?", submission.ContestID).First(&contest).Error; err != nil {
+			return ev
+		}
+		if contestResultsHidden(sc.db, &contest, submission) {
+			ev.Verdict = ""
+		}
+		return ev
+	}
+}
+
+// streamEvents 持续转发订阅到的事件，直到客户端断开；stopOnFinish 为真时收到结束事件后返回，
+// redact 不为 nil 时事件先经其过滤再写出
+func streamEvents(c *gin.Context, sub *services.EventSubscription, stopOnFinish bool, redact func(services.JudgeEvent) services.JudgeEvent) {
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if redact != nil {
+				ev = redact(ev)
+			}
+			writeEvent(c, ev)
+			if stopOnFinish && ev.Type == services.EventFinished {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamSubmission 以 SSE 推送单个提交的评测进度，评测结束后关闭连接（仅提交者本人或管理员）
+func (sc *SubmissionController) StreamSubmission(c *gin.Context) {
+	op, ok := requireOperatorUUID(sc.db, c)
+	if !ok {
+		return
+	}
+	submissionID := c.Param("id")
+
+	// 先订阅再读取当前状态，避免两者之间产生的事件丢失
+	events := sc.judgeService.Events
+	sub := events.Subscribe(func(ev services.JudgeEvent) bool {
+		return ev.SubmissionID == submissionID
+	})
+	defer events.Unsubscribe(sub)
+
+	var submission models.Submission
+	if err := sc.db.Where("id = ?", submissionID).First(&submission).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提交记录不存在"})
+		return
+	}
+	if !canAccessUserState(op, submission.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return
+	}
+
+	redact := sc.resultRedactor(util.UserInstance.HasPermission(op, "admin"))
+	prepareSSE(c)
+	snapshot := sc.snapshotEvent(&submission)
+	if redact != nil {
+		snapshot = redact(snapshot)
+	}
+	writeEvent(c, snapshot)
+	if snapshot.Type == services.EventFinished {
+		return
+	}
+	streamEvents(c, sub, true, redact)
+}
+
+// StreamUserSubmissions 以 SSE 推送某个用户全部提交的评测进度（比赛实时状态页，仅本人或管理员）
+func (sc *SubmissionController) StreamUserSubmissions(c *gin.Context) {
+	op, ok := requireOperatorUUID(sc.db, c)
+	if !ok {
+		return
+	}
+	userID := strings.TrimSpace(c.Query("user_id"))
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 user_id"})
+		return
+	}
+	if !canAccessUserState(op, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权限"})
+		return
+	}
+
+	events := sc.judgeService.Events
+	sub := events.Subscribe(func(ev services.JudgeEvent) bool {
+		return ev.UserID == userID
+	})
+	defer events.Unsubscribe(sub)
+
+	var active []models.Submission
+	if err := sc.db.Where("user_id = ? AND status IN ?", userID, []string{"pending", "processing"}).
+		Order("created_at ASC").Find(&active).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询提交记录失败"})
+		return
+	}
+
+	redact := sc.resultRedactor(util.UserInstance.HasPermission(op, "admin"))
+	prepareSSE(c)
+	for i := range active {
+		writeEvent(c, sc.snapshotEvent(&active[i]))
+	}
+	streamEvents(c, sub, false, redact)
+}
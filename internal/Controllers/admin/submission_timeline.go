@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"dachuang/internal/models"
+	"dachuang/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timelineStatsMaxSamples 单次统计最多读取的提交数
+const timelineStatsMaxSamples = 5000
+
+// timelineStageOrder 统计结果中各阶段的展示顺序
+var timelineStageOrder = []string{
+	services.TimelineQueue, services.TimelineFetch, services.TimelineCache, services.TimelineCompile,
+	services.TimelineRun, services.TimelineCase, services.TimelineCompare, services.TimelineSave,
+}
+
+// stageStats 单个阶段的耗时分布（毫秒）
+type stageStats struct {
+	Stage string  `json:"stage"`
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// percentile 已排序样本的 p 分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// GetTimelineStats 最近评测各阶段耗时的分位数（管理员）
+// hours 为统计窗口（默认 24），可按 language、question_id 过滤；
+// 同一次评测中重复出现的阶段（如多次读取测试数据）合并计算，case 按单个测试点统计
+func (sc *SubmissionController) GetTimelineStats(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if hours < 1 {
+		hours = 24
+	}
+
+	q := sc.db.Model(&models.Submission{}).
+		Where("timeline <> '' AND updated_at >= ?", time.Now().Add(-time.Duration(hours)*time.Hour))
+	if lang := c.Query("language"); lang != "" {
+		q = q.Where("language = ?", lang)
+	}
+	if qid := c.Query("question_id"); qid != "" {
+		q = q.Where("question_id = ?", qid)
+	}
+	var timelines []string
+	if err := q.Order("updated_at DESC").Limit(timelineStatsMaxSamples).Pluck("timeline", &timelines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	samples := make(map[string][]float64)
+	for _, raw := range timelines {
+		totals := make(map[string]float64)
+		for _, st := range services.ParseTimeline(raw) {
+			if st.Stage == services.TimelineCase {
+				samples[st.Stage] = append(samples[st.Stage], st.DurationMs)
+				continue
+			}
+			totals[st.Stage] += st.DurationMs
+		}
+		for stage, ms := range totals {
+			samples[stage] = append(samples[stage], ms)
+		}
+	}
+
+	stats := make([]stageStats, 0, len(timelineStageOrder))
+	for _, stage := range timelineStageOrder {
+		values := samples[stage]
+		if len(values) == 0 {
+			continue
+		}
+		sort.Float64s(values)
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		stats = append(stats, stageStats{
+			Stage: stage,
+			Count: len(values),
+			Avg:   sum / float64(len(values)),
+			P50:   percentile(values, 50),
+			P90:   percentile(values, 90),
+			P99:   percentile(values, 99),
+			Max:   values[len(values)-1],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats, "submissions": len(timelines), "hours": hours})
+}
@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"dachuang/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubtaskController 子任务设置（仅管理员）
+type SubtaskController struct {
+	db *gorm.DB
+}
+
+// NewSubtaskController 创建子任务控制器
+func NewSubtaskController(db *gorm.DB) *SubtaskController {
+	return &SubtaskController{db: db}
+}
+
+// SubtaskRequest 子任务及其包含的测试用例
+type SubtaskRequest struct {
+	ID    int    `json:"id"`
+	Score int    `json:"score"`
+	Cases []uint `json:"cases"` // 测试用例 ID
+}
+
+// SubtasksRequest 设置子任务请求，subtasks 为空表示取消子任务
+type SubtasksRequest struct {
+	Subtasks []SubtaskRequest `json:"subtasks"`
+}
+
+// Show 子任务配置与各子任务包含的测试用例
+func (sc *SubtaskController) Show(c *gin.Context) {
+	question, ok := findQuestionByNumber(sc.db, c)
+	if !ok {
+		return
+	}
+	var testCases []models.TestCase
+	if err := sc.db.Select("id", "subtask").Where("question_id = ?", question.Id).Order("id ASC").Find(&testCases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询测试用例失败"})
+		return
+	}
+	cases := make(map[int][]uint)
+	for _, tc := range testCases {
+		cases[tc.Subtask] = append(cases[tc.Subtask], tc.ID)
+	}
+
+	subtasks := make([]SubtaskRequest, 0)
+	for _, st := range question.SubtaskList() {
+		subtasks = append(subtasks, SubtaskRequest{ID: st.ID, Score: st.Score, Cases: cases[st.ID]})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": subtasks, "full_score": question.FullScore(), "unassigned": cases[0]})
+}
+
+// Save 设置子任务：每个测试用例最多属于一个子任务，未列出的测试用例不计分
+func (sc *SubtaskController) Save(c *gin.Context) {
+	if _, ok := requireAdmin(sc.db, c); !ok {
+		return
+	}
+	question, ok := findQuestionByNumber(sc.db, c)
+	if !ok {
+		return
+	}
+	var req SubtasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var caseIDs []uint
+	if err := sc.db.Model(&models.TestCase{}).Where("question_id = ?", question.Id).Pluck("id", &caseIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询测试用例失败"})
+		return
+	}
+	owned := make(map[uint]bool, len(caseIDs))
+	for _, id := range caseIDs {
+		owned[id] = true
+	}
+
+	subtasks := make([]models.Subtask, 0, len(req.Subtasks))
+	ids := make(map[int]bool)
+	assigned := make(map[uint]int)
+	for _, st := range req.Subtasks {
+		var msg string
+		switch {
+		case st.ID <= 0:
+			msg = "子任务 id 必须为正整数"
+		case ids[st.ID]:
+			msg = fmt.Sprintf("子任务 %d 重复", st.ID)
+		case st.Score <= 0:
+			msg = fmt.Sprintf("子任务 %d 的分值必须为正数", st.ID)
+		case len(st.Cases) == 0:
+			msg = fmt.Sprintf("子任务 %d 没有测试用例", st.ID)
+		}
+		for _, id := range st.Cases {
+			if msg != "" {
+				break
+			}
+			if !owned[id] {
+				msg = fmt.Sprintf("测试用例 %d 不属于该题", id)
+			} else if other, ok := assigned[id]; ok {
+				msg = fmt.Sprintf("测试用例 %d 同时属于子任务 %d 和 %d", id, other, st.ID)
+			}
+			assigned[id] = st.ID
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		ids[st.ID] = true
+		subtasks = append(subtasks, models.Subtask{ID: st.ID, Score: st.Score})
+	}
+
+	config := ""
+	if len(subtasks) > 0 {
+		data, _ := json.Marshal(subtasks)
+		config = string(data)
+	}
+	err := sc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Question{}).Where("id = ?", question.Id).Update("subtasks", config).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TestCase{}).Where("question_id = ?", question.Id).Update("subtask", 0).Error; err != nil {
+			return err
+		}
+		for _, st := range req.Subtasks {
+			if err := tx.Model(&models.TestCase{}).Where("id IN ?", st.Cases).Update("subtask", st.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存子任务失败: " + err.Error()})
+		return
+	}
+	question.Subtasks = config
+	c.JSON(http.StatusOK, gin.H{"data": subtasks, "full_score": question.FullScore(), "msg": "子任务已保存"})
+}
@@ -2,12 +2,15 @@ package admin
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"dachuang/internal/config"
 	"dachuang/internal/models"
 	"dachuang/internal/oss"
+	"dachuang/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,10 +18,11 @@ import (
 // TestCaseController 测试用例控制器
 type TestCaseController struct {
 	ossClient *oss.OSS
+	setter    *services.ProblemSetterService
 }
 
 func NewTestCaseController(ossClient *oss.OSS) *TestCaseController {
-	return &TestCaseController{ossClient: ossClient}
+	return &TestCaseController{ossClient: ossClient, setter: newProblemSetter(models.DB, ossClient)}
 }
 
 // TestCaseRequest 测试用例请求结构体
@@ -27,6 +31,9 @@ type TestCaseRequest struct {
 	Input          string `json:"input" binding:"required"`           // 输入数据
 	ExpectedOutput string `json:"expected_output" binding:"required"` // 期望输出
 	IsHidden       bool   `json:"is_hidden"`                          // 是否隐藏测试用例
+	IsSample       bool   `json:"is_sample"`                          // 是否为样例
+	SampleOrder    int    `json:"sample_order"`                       // 样例顺序
+	RejectInvalid  bool   `json:"reject_invalid"`                     // 输入未通过校验器时拒绝保存（默认仅标记）
 }
 
 // BatchTestCaseRequest 批量添加测试用例请求结构体
@@ -36,7 +43,10 @@ type BatchTestCaseRequest struct {
 		Input          string `json:"input" binding:"required"`           // 输入数据
 		ExpectedOutput string `json:"expected_output" binding:"required"` // 期望输出
 		IsHidden       bool   `json:"is_hidden"`                          // 是否隐藏测试用例
+		IsSample       bool   `json:"is_sample"`                          // 是否为样例
+		SampleOrder    int    `json:"sample_order"`                       // 样例顺序
 	} `json:"test_cases" binding:"required,min=1"` // 测试用例列表，至少包含一个
+	RejectInvalid bool `json:"reject_invalid"` // 任一输入未通过校验器时整批拒绝
 }
 
 type OSSTestCaseCommitRequest struct {
@@ -44,6 +54,48 @@ type OSSTestCaseCommitRequest struct {
 	InputKey       string `json:"input_key" binding:"required"`
 	OutputKey      string `json:"output_key" binding:"required"`
 	IsHidden       bool   `json:"is_hidden"`
+	IsSample       bool   `json:"is_sample"`
+	SampleOrder    int    `json:"sample_order"`
+	RejectInvalid  bool   `json:"reject_invalid"`
+}
+
+// errHiddenSample 样例会在题面中公开展示，不能同时设置为隐藏
+const errHiddenSample = "样例测试用例不能设置为隐藏"
+
+// checkInputs 用题目的输入校验器检查输入，题目没有校验器时返回 nil
+// reject 为 true 且存在非法输入时直接返回 422
+func (tc *TestCaseController) checkInputs(c *gin.Context, questionID int, reject bool, load func() ([]string, error)) ([]services.InputCheck, bool) {
+	validator, err := tc.setter.InputValidator(questionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if validator == nil {
+		return nil, true
+	}
+	inputs, err := load()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	checks, err := tc.setter.CheckInputs(validator, inputs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "输入校验失败: " + err.Error()})
+		return nil, false
+	}
+	if reject {
+		var invalid []services.InputCheck
+		for _, check := range checks {
+			if !check.Valid {
+				invalid = append(invalid, check)
+			}
+		}
+		if len(invalid) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "测试输入未通过校验", "invalid": invalid})
+			return nil, false
+		}
+	}
+	return checks, true
 }
 
 // Index 获取测试用例列表
@@ -132,12 +184,29 @@ func (tc *TestCaseController) Store(c *gin.Context) {
 		return
 	}
 
+	if request.IsSample && request.IsHidden {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errHiddenSample})
+		return
+	}
+
+	checks, ok := tc.checkInputs(c, question.Id, request.RejectInvalid, func() ([]string, error) {
+		return []string{request.Input}, nil
+	})
+	if !ok {
+		return
+	}
+
 	// 创建测试用例
 	testCase := models.TestCase{
 		QuestionID:     question.Id, // 使用题目的数据库ID
 		Input:          request.Input,
 		ExpectedOutput: request.ExpectedOutput,
 		IsHidden:       request.IsHidden,
+		IsSample:       request.IsSample,
+		SampleOrder:    request.SampleOrder,
+	}
+	if len(checks) > 0 {
+		checks[0].Apply(&testCase)
 	}
 
 	// 保存到数据库
@@ -145,6 +214,7 @@ func (tc *TestCaseController) Store(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建测试用例失败"})
 		return
 	}
+	models.TestDataChanged(question.Id)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "测试用例创建成功",
@@ -171,20 +241,41 @@ func (tc *TestCaseController) BatchStore(c *gin.Context) {
 	// 准备批量插入的测试用例
 	var testCases []models.TestCase
 	for _, tc := range request.TestCases {
+		if tc.IsSample && tc.IsHidden {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errHiddenSample})
+			return
+		}
 		testCase := models.TestCase{
 			QuestionID:     question.Id, // 使用题目的数据库ID
 			Input:          tc.Input,
 			ExpectedOutput: tc.ExpectedOutput,
 			IsHidden:       tc.IsHidden,
+			IsSample:       tc.IsSample,
+			SampleOrder:    tc.SampleOrder,
 		}
 		testCases = append(testCases, testCase)
 	}
 
+	checks, ok := tc.checkInputs(c, question.Id, request.RejectInvalid, func() ([]string, error) {
+		inputs := make([]string, len(testCases))
+		for i := range testCases {
+			inputs[i] = testCases[i].Input
+		}
+		return inputs, nil
+	})
+	if !ok {
+		return
+	}
+	for i := range checks {
+		checks[i].Apply(&testCases[i])
+	}
+
 	// 批量插入到数据库
 	if err := models.DB.Create(&testCases).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量创建测试用例失败"})
 		return
 	}
+	models.TestDataChanged(question.Id)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "批量创建测试用例成功",
@@ -204,6 +295,10 @@ func (tc *TestCaseController) OSSCommit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.IsSample && request.IsHidden {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errHiddenSample})
+		return
+	}
 
 	var question models.Question
 	if err := models.DB.Where("question_number = ?", request.QuestionNumber).First(&question).Error; err != nil {
@@ -217,32 +312,38 @@ func (tc *TestCaseController) OSSCommit(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	inInfo, err := tc.ossClient.StatObject(ctx, bucket, request.InputKey)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "input_key 不存在或不可访问: " + err.Error()})
-		return
-	}
-	outInfo, err := tc.ossClient.StatObject(ctx, bucket, request.OutputKey)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "output_key 不存在或不可访问: " + err.Error()})
+	checks, ok := tc.checkInputs(c, question.Id, request.RejectInvalid, func() ([]string, error) {
+		b, err := tc.ossClient.GetObjectBytes(ctx, bucket, request.InputKey)
+		if err != nil {
+			return nil, fmt.Errorf("input_key 不存在或不可访问: %w", err)
+		}
+		return []string{string(b)}, nil
+	})
+	if !ok {
 		return
 	}
 
-	testCase := models.TestCase{
+	// 同一题目下 input_key 相同则覆盖原用例
+	testCase, err := services.CommitOSSTestCase(ctx, models.DB, tc.ossClient, bucket, services.OSSTestCaseSpec{
 		QuestionID:  question.Id,
 		InputKey:    request.InputKey,
 		OutputKey:   request.OutputKey,
-		InputSize:   inInfo.Size,
-		OutputSize:  outInfo.Size,
 		IsHidden:    request.IsHidden,
-		Input:       "",
-		ExpectedOutput: "",
-	}
-
-	if err := models.DB.Create(&testCase).Error; err != nil {
+		IsSample:    request.IsSample,
+		SampleOrder: request.SampleOrder,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrTestCaseObject) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建测试用例失败"})
 		return
 	}
+	if len(checks) > 0 {
+		checks[0].Apply(testCase)
+		models.DB.Model(testCase).Select("validation_status", "validation_message").Updates(testCase)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "测试用例创建成功",
@@ -295,6 +396,8 @@ func (tc *TestCaseController) Update(c *gin.Context) {
 		return
 	}
 
+	oldQuestionID := testCase.QuestionID
+
 	// 如果更改了题目编号，需要验证新题目是否存在
 	if request.QuestionNumber != 0 {
 		// 通过题目编号查找题目
@@ -306,15 +409,38 @@ func (tc *TestCaseController) Update(c *gin.Context) {
 		testCase.QuestionID = question.Id // 使用题目的数据库ID
 	}
 
+	if request.IsSample && request.IsHidden {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errHiddenSample})
+		return
+	}
+
+	checks, ok := tc.checkInputs(c, testCase.QuestionID, request.RejectInvalid, func() ([]string, error) {
+		return []string{request.Input}, nil
+	})
+	if !ok {
+		return
+	}
+
 	// 更新测试用例
 	testCase.Input = request.Input
 	testCase.ExpectedOutput = request.ExpectedOutput
 	testCase.IsHidden = request.IsHidden
+	testCase.IsSample = request.IsSample
+	testCase.SampleOrder = request.SampleOrder
+	testCase.ValidationStatus = ""
+	testCase.ValidationMessage = ""
+	if len(checks) > 0 {
+		checks[0].Apply(&testCase)
+	}
 
 	if err := models.DB.Save(&testCase).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新测试用例失败"})
 		return
 	}
+	models.TestDataChanged(testCase.QuestionID)
+	if oldQuestionID != testCase.QuestionID {
+		models.TestDataChanged(oldQuestionID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "测试用例更新成功",
@@ -344,6 +470,7 @@ func (tc *TestCaseController) Delete(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除测试用例失败"})
 		return
 	}
+	models.TestDataChanged(testCase.QuestionID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "测试用例删除成功",
@@ -53,7 +53,7 @@ func (uc *UserController) Show(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": user})
+	c.JSON(http.StatusOK, gin.H{"result": user, "rating": userRating(uc.db, user.UUID)})
 }
 
 // Update 更新用户信息
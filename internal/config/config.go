@@ -16,6 +16,7 @@ type Config struct {
 	Log           LogConfig           `mapstructure:"log"`
 	OSS           OSSConfig           `mapstructure:"oss"`
 	AI            AIConfig            `mapstructure:"ai"`
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
 }
 
 // AIConfig AI服务配置
@@ -79,16 +80,51 @@ type Neo4jConfig struct {
 type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
+	// 信任的反向代理地址（IP 或 CIDR），只有来自这些地址的请求才采用 X-Forwarded-For 作为客户端 IP，留空表示不信任任何代理
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // JudgeConfig 评测服务配置
 type JudgeConfig struct {
-	Mode      string           `mapstructure:"mode"`
-	APIURL    string           `mapstructure:"api_url"`
-	Timeout   int              `mapstructure:"timeout"`
-	QueueSize int              `mapstructure:"queue_size"`
-	Local     LocalJudgeConfig `mapstructure:"local"`
-	GoJudge   GoJudgeConfig    `mapstructure:"go_judge"`
+	Mode        string           `mapstructure:"mode"`
+	APIURL      string           `mapstructure:"api_url"`
+	Timeout     int              `mapstructure:"timeout"`
+	QueueSize   int              `mapstructure:"queue_size"`
+	QueueAging  int              `mapstructure:"queue_aging"`  // 排队每满该秒数优先级提升一级，防止低优先级提交饿死
+	ResultCache bool             `mapstructure:"result_cache"` // 相同代码重复提交时复用已有评测结果
+	Local       LocalJudgeConfig `mapstructure:"local"`
+	GoJudge     GoJudgeConfig    `mapstructure:"go_judge"`
+
+	OutputLimit OutputLimitConfig `mapstructure:"output_limit"`
+	MultiFile   MultiFileConfig   `mapstructure:"multi_file"`
+	Retry       RetryConfig       `mapstructure:"retry"`
+}
+
+// RetryConfig 系统错误（评测机、沙箱、OSS 等故障）的自动重试与告警配置
+// 第 n 次重试延迟 base_delay * 2^(n-1) 秒，不超过 max_delay；尝试 max_attempts 次仍失败时告警
+type RetryConfig struct {
+	MaxAttempts  int    `mapstructure:"max_attempts"`
+	BaseDelay    int    `mapstructure:"base_delay"`
+	MaxDelay     int    `mapstructure:"max_delay"`
+	AlertWebhook string `mapstructure:"alert_webhook"` // 为空时只写日志
+}
+
+// MultiFileConfig 多文件/压缩包提交配置
+// Build 为各语言在项目目录中执行的构建命令（sh -c），需产出约定的可执行文件：
+// go/cpp 为 ./main，java 为 main.jar（入口类 Main），python 无需构建，入口为 main.py
+type MultiFileConfig struct {
+	MaxFiles   int               `mapstructure:"max_files"`
+	MaxFileKB  int               `mapstructure:"max_file_kb"`
+	MaxTotalKB int               `mapstructure:"max_total_kb"`
+	Build      map[string]string `mapstructure:"build"`
+}
+
+// OutputLimitConfig 输出上限配置（题目未单独设置 output_limit 时使用）
+// 上限 = 期望输出大小 * factor，并限制在 [min_kb, max_kb] 之间
+type OutputLimitConfig struct {
+	Factor float64 `mapstructure:"factor"`
+	MinKB  int     `mapstructure:"min_kb"`
+	MaxKB  int     `mapstructure:"max_kb"`
 }
 
 type GoJudgeConfig struct {
@@ -116,6 +152,32 @@ type LocalJudgeConfig struct {
 	DockerImageJava   string `mapstructure:"docker_image_java"`
 }
 
+// RateLimitConfig 提交频率限制配置
+type RateLimitConfig struct {
+	Enabled  bool           `mapstructure:"enabled"`
+	Store    string         `mapstructure:"store"` // memory / database，多实例部署时使用 database
+	Practice RateLimitRules `mapstructure:"practice"`
+	Contest  RateLimitRules `mapstructure:"contest"` // 比赛提交的独立限制，未配置时沿用 practice
+}
+
+// RateLimitRules 各维度的令牌桶限制
+type RateLimitRules struct {
+	User    TokenBucketConfig `mapstructure:"user"`
+	IP      TokenBucketConfig `mapstructure:"ip"`
+	Problem TokenBucketConfig `mapstructure:"problem"` // 同一用户对同一题目（冷却）
+}
+
+// IsZero 是否未配置任何限制
+func (r RateLimitRules) IsZero() bool {
+	return r.User.Capacity == 0 && r.IP.Capacity == 0 && r.Problem.Capacity == 0
+}
+
+// TokenBucketConfig 令牌桶：最多 capacity 个令牌，每 refill_seconds 秒补充一个；capacity 为 0 表示不限制
+type TokenBucketConfig struct {
+	Capacity      int     `mapstructure:"capacity"`
+	RefillSeconds float64 `mapstructure:"refill_seconds"`
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level    string `mapstructure:"level"`
@@ -168,6 +230,11 @@ func setDefaults() {
 	viper.SetDefault("judge.mode", "local")
 	viper.SetDefault("judge.timeout", 15)
 	viper.SetDefault("judge.queue_size", 100)
+	viper.SetDefault("judge.queue_aging", 60)
+	viper.SetDefault("judge.result_cache", true)
+	viper.SetDefault("judge.retry.max_attempts", 5)
+	viper.SetDefault("judge.retry.base_delay", 10)
+	viper.SetDefault("judge.retry.max_delay", 600)
 	viper.SetDefault("judge.local.enabled", true)
 	viper.SetDefault("judge.local.sandbox_dir", "./sandbox")
 	viper.SetDefault("judge.local.max_memory", 128)
@@ -175,6 +242,17 @@ func setDefaults() {
 	viper.SetDefault("judge.local.max_output_size", 1024)
 	viper.SetDefault("judge.local.supported_languages", []string{"go", "python", "cpp", "java"})
 
+	viper.SetDefault("judge.output_limit.factor", 2.0)
+	viper.SetDefault("judge.output_limit.min_kb", 64)
+	viper.SetDefault("judge.output_limit.max_kb", 64*1024)
+
+	viper.SetDefault("judge.multi_file.max_files", 64)
+	viper.SetDefault("judge.multi_file.max_file_kb", 256)
+	viper.SetDefault("judge.multi_file.max_total_kb", 1024)
+	viper.SetDefault("judge.multi_file.build.go", "[ -f go.mod ] || go mod init main >/dev/null 2>&1; go build -o main .")
+	viper.SetDefault("judge.multi_file.build.cpp", "g++ -O2 -std=c++17 -I. -o main $(find . -name '*.cpp' -o -name '*.cc')")
+	viper.SetDefault("judge.multi_file.build.java", "javac -d . $(find . -name '*.java') && jar cf main.jar $(find . -name '*.class')")
+
 	viper.SetDefault("judge.go_judge.max_memory", 256)
 	viper.SetDefault("judge.go_judge.max_time", 5000)
 	viper.SetDefault("judge.local.executor", "host")
@@ -183,6 +261,16 @@ func setDefaults() {
 	viper.SetDefault("judge.local.docker_image_python", "python:3.12-bookworm")
 	viper.SetDefault("judge.local.docker_image_java", "eclipse-temurin:21-jdk")
 
+	// 提交频率限制默认配置
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.store", "memory")
+	viper.SetDefault("rate_limit.practice.user.capacity", 10)
+	viper.SetDefault("rate_limit.practice.user.refill_seconds", 6)
+	viper.SetDefault("rate_limit.practice.ip.capacity", 30)
+	viper.SetDefault("rate_limit.practice.ip.refill_seconds", 2)
+	viper.SetDefault("rate_limit.practice.problem.capacity", 3)
+	viper.SetDefault("rate_limit.practice.problem.refill_seconds", 10)
+
 	// Oss默认公开读取前缀
 	viper.SetDefault("oss.public_read_prefixes", []string{})
 
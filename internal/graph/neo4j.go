@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
+
+	"dachuang/internal/metrics"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
@@ -151,7 +154,10 @@ func (c *Neo4jClient) ExecuteQuery(ctx context.Context, query string, params map
 	})
 	defer session.Close(ctx)
 
-	return session.Run(ctx, query, params)
+	start := time.Now()
+	result, err := session.Run(ctx, query, params)
+	metrics.ObserveNeo4j("query", start, err)
+	return result, err
 }
 
 // ExecuteWrite 执行写操作
@@ -161,7 +167,10 @@ func (c *Neo4jClient) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransa
 	})
 	defer session.Close(ctx)
 
-	return session.ExecuteWrite(ctx, work)
+	start := time.Now()
+	result, err := session.ExecuteWrite(ctx, work)
+	metrics.ObserveNeo4j("write", start, err)
+	return result, err
 }
 
 // ExecuteRead 执行读操作
@@ -171,5 +180,8 @@ func (c *Neo4jClient) ExecuteRead(ctx context.Context, work neo4j.ManagedTransac
 	})
 	defer session.Close(ctx)
 
-	return session.ExecuteRead(ctx, work)
+	start := time.Now()
+	result, err := session.ExecuteRead(ctx, work)
+	metrics.ObserveNeo4j("read", start, err)
+	return result, err
 }
\ No newline at end of file
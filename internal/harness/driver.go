@@ -0,0 +1,538 @@
+package harness
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// File 合并后程序中的一个源文件
+type File struct {
+	Path    string
+	Content string
+}
+
+// Program 用户代码与驱动程序合并后的多文件程序
+// 用户代码单独成文件（solution.go / solution.cpp / Solution.java / solution.py），编译错误的行号即用户代码的行号
+type Program struct {
+	Files        []File
+	BuildCommand string // 构建产物约定与多文件提交一致：./main 或 main.jar，python 无需构建
+}
+
+// Build 将用户实现的函数与驱动程序合并：驱动程序读取测试输入、调用函数并按规范格式输出返回值
+func Build(sig *Signature, language, code string) (*Program, error) {
+	switch language {
+	case "go":
+		return &Program{
+			Files:        []File{{Path: "solution.go", Content: goSolution(code)}, {Path: "main.go", Content: goDriver(sig)}},
+			BuildCommand: "go build -o main main.go solution.go",
+		}, nil
+	case "cpp":
+		return &Program{
+			Files:        []File{{Path: "solution.cpp", Content: code}, {Path: "main.cpp", Content: cppDriver(sig)}},
+			BuildCommand: "g++ -O2 -std=c++17 -o main main.cpp",
+		}, nil
+	case "java":
+		return &Program{
+			Files:        []File{{Path: "Solution.java", Content: code}, {Path: "Main.java", Content: javaDriver(sig)}},
+			BuildCommand: "javac -encoding UTF-8 -d . Main.java Solution.java && jar cf main.jar *.class",
+		}, nil
+	case "python":
+		return &Program{
+			Files: []File{{Path: "solution.py", Content: code}, {Path: "main.py", Content: pythonDriver(sig)}},
+		}, nil
+	}
+	return nil, fmt.Errorf("函数签名题不支持语言 %q", language)
+}
+
+var goPackageClause = regexp.MustCompile(`(?m)^\s*package\s+\w+`)
+
+// goSolution 用户代码缺少 package 声明时补在第一行行首，不改变行号
+func goSolution(code string) string {
+	if goPackageClause.MatchString(code) {
+		return code
+	}
+	return "package main; " + code
+}
+
+func goDriver(sig *Signature) string {
+	var b strings.Builder
+	b.WriteString(`package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+func main() {
+	harnessIn := json.NewDecoder(bufio.NewReader(os.Stdin))
+`)
+	args := make([]string, len(sig.Params))
+	for i, t := range sig.paramTypes() {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "\tvar %s %s\n\tharnessRead(harnessIn, &%s, %q)\n", args[i], goType(t), args[i], sig.Params[i].Name)
+	}
+	fmt.Fprintf(&b, `	harnessOut := bufio.NewWriter(os.Stdout)
+	harnessWrite(harnessOut, reflect.ValueOf(%s(%s)))
+	harnessOut.WriteString("\n")
+	harnessOut.Flush()
+}
+`, sig.Function, strings.Join(args, ", "))
+	b.WriteString(`
+func harnessRead(dec *json.Decoder, v interface{}, name string) {
+	if err := dec.Decode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "harness: 读取参数 %s 失败: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func harnessWrite(w *bufio.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Slice:
+		w.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			harnessWrite(w, v.Index(i))
+		}
+		w.WriteByte(']')
+	case reflect.Float64:
+		w.WriteString(strconv.FormatFloat(v.Float(), 'f', 5, 64))
+	case reflect.Bool:
+		w.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.String:
+		s := v.String()
+		w.WriteByte('"')
+		for i := 0; i < v.Len(); i++ {
+			switch s[i] {
+			case '"':
+				w.WriteString("\\\"")
+			case '\\':
+				w.WriteString("\\\\")
+			case '\n':
+				w.WriteString("\\n")
+			case '\r':
+				w.WriteString("\\r")
+			case '\t':
+				w.WriteString("\\t")
+			default:
+				w.WriteByte(s[i])
+			}
+		}
+		w.WriteByte('"')
+	default:
+		w.WriteString(strconv.FormatInt(v.Int(), 10))
+	}
+}
+`)
+	return b.String()
+}
+
+// cppDriverHeader C++ 驱动程序：先包含用户代码，再定义读写函数，main 在最后
+const cppDriverHeader = `#include <bits/stdc++.h>
+using namespace std;
+
+#include "solution.cpp"
+
+namespace harness {
+struct Reader {
+    string s;
+    size_t i = 0;
+    void skip() {
+        while (i < s.size() && isspace((unsigned char)s[i])) i++;
+    }
+    [[noreturn]] void fail(const char* what) {
+        cerr << "harness: 输入格式错误（" << what << "），位置 " << i << endl;
+        exit(1);
+    }
+    void expect(char c) {
+        skip();
+        if (i >= s.size() || s[i] != c) fail("缺少分隔符");
+        i++;
+    }
+    bool accept(char c) {
+        skip();
+        if (i < s.size() && s[i] == c) {
+            i++;
+            return true;
+        }
+        return false;
+    }
+};
+
+inline void read(Reader& r, long long& v) {
+    r.skip();
+    const char* b = r.s.c_str() + r.i;
+    char* e;
+    v = strtoll(b, &e, 10);
+    if (e == b) r.fail("整数");
+    r.i += e - b;
+}
+inline void read(Reader& r, int& v) {
+    long long x;
+    read(r, x);
+    v = (int)x;
+}
+inline void read(Reader& r, double& v) {
+    r.skip();
+    const char* b = r.s.c_str() + r.i;
+    char* e;
+    v = strtod(b, &e);
+    if (e == b) r.fail("浮点数");
+    r.i += e - b;
+}
+inline void read(Reader& r, bool& v) {
+    r.skip();
+    if (r.s.compare(r.i, 4, "true") == 0) {
+        v = true;
+        r.i += 4;
+    } else if (r.s.compare(r.i, 5, "false") == 0) {
+        v = false;
+        r.i += 5;
+    } else {
+        r.fail("布尔值");
+    }
+}
+inline void read(Reader& r, string& v) {
+    r.expect('"');
+    v.clear();
+    while (r.i < r.s.size() && r.s[r.i] != '"') {
+        char c = r.s[r.i++];
+        if (c == '\\' && r.i < r.s.size()) {
+            c = r.s[r.i++];
+            if (c == 'n') c = '\n';
+            else if (c == 't') c = '\t';
+            else if (c == 'r') c = '\r';
+        }
+        v += c;
+    }
+    r.expect('"');
+}
+template <class T>
+void read(Reader& r, vector<T>& v) {
+    r.expect('[');
+    v.clear();
+    if (r.accept(']')) return;
+    do {
+        T x;
+        read(r, x);
+        v.push_back(x);
+    } while (r.accept(','));
+    r.expect(']');
+}
+
+inline void write(string& o, int v) { o += to_string(v); }
+inline void write(string& o, long long v) { o += to_string(v); }
+inline void write(string& o, double v) {
+    char b[64];
+    snprintf(b, sizeof b, "%.5f", v);
+    o += b;
+}
+inline void write(string& o, bool v) { o += v ? "true" : "false"; }
+inline void write(string& o, const string& v) {
+    o += '"';
+    for (char c : v) {
+        if (c == '"') o += "\\\"";
+        else if (c == '\\') o += "\\\\";
+        else if (c == '\n') o += "\\n";
+        else if (c == '\r') o += "\\r";
+        else if (c == '\t') o += "\\t";
+        else o += c;
+    }
+    o += '"';
+}
+template <class T>
+void write(string& o, const vector<T>& v) {
+    o += '[';
+    for (size_t i = 0; i < v.size(); i++) {
+        if (i) o += ',';
+        T x = v[i];
+        write(o, x);
+    }
+    o += ']';
+}
+}  // namespace harness
+
+int main() {
+    harness::Reader r;
+    r.s.assign(istreambuf_iterator<char>(cin), istreambuf_iterator<char>());
+`
+
+func cppDriver(sig *Signature) string {
+	var b strings.Builder
+	b.WriteString(cppDriverHeader)
+	args := make([]string, len(sig.Params))
+	for i, t := range sig.paramTypes() {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "    %s %s;\n    harness::read(r, %s);\n", cppType(t), args[i], args[i])
+	}
+	fmt.Fprintf(&b, `    Solution sol;
+    %s res = sol.%s(%s);
+    string out;
+    harness::write(out, res);
+    cout << out << '\n';
+    return 0;
+}
+`, cppType(sig.returnType()), sig.Function, strings.Join(args, ", "))
+	return b.String()
+}
+
+// javaDriverHeader Java 驱动程序中与签名无关的部分：输入解析与标量读写
+const javaDriverHeader = `import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.Locale;
+
+public class Main {
+    static String in;
+    static int pos;
+
+    static void fail(String what) {
+        System.err.println("harness: 输入格式错误（" + what + "），位置 " + pos);
+        System.exit(1);
+    }
+
+    static void skip() {
+        while (pos < in.length() && Character.isWhitespace(in.charAt(pos))) pos++;
+    }
+
+    static void expect(char c) {
+        skip();
+        if (pos >= in.length() || in.charAt(pos) != c) fail("缺少 " + c);
+        pos++;
+    }
+
+    static boolean accept(char c) {
+        skip();
+        if (pos < in.length() && in.charAt(pos) == c) {
+            pos++;
+            return true;
+        }
+        return false;
+    }
+
+    static String number() {
+        skip();
+        int start = pos;
+        while (pos < in.length() && "+-.eE0123456789".indexOf(in.charAt(pos)) >= 0) pos++;
+        if (start == pos) fail("数字");
+        return in.substring(start, pos);
+    }
+
+    static int readInt() {
+        try {
+            return Integer.parseInt(number());
+        } catch (NumberFormatException e) {
+            fail("整数");
+            return 0;
+        }
+    }
+
+    static long readLong() {
+        try {
+            return Long.parseLong(number());
+        } catch (NumberFormatException e) {
+            fail("整数");
+            return 0;
+        }
+    }
+
+    static double readDouble() {
+        try {
+            return Double.parseDouble(number());
+        } catch (NumberFormatException e) {
+            fail("浮点数");
+            return 0;
+        }
+    }
+
+    static boolean readBool() {
+        skip();
+        if (in.startsWith("true", pos)) {
+            pos += 4;
+            return true;
+        }
+        if (in.startsWith("false", pos)) {
+            pos += 5;
+            return false;
+        }
+        fail("布尔值");
+        return false;
+    }
+
+    static String readString() {
+        expect('"');
+        StringBuilder sb = new StringBuilder();
+        while (pos < in.length() && in.charAt(pos) != '"') {
+            char c = in.charAt(pos++);
+            if (c == '\\' && pos < in.length()) {
+                c = in.charAt(pos++);
+                if (c == 'n') c = '\n';
+                else if (c == 't') c = '\t';
+                else if (c == 'r') c = '\r';
+            }
+            sb.append(c);
+        }
+        expect('"');
+        return sb.toString();
+    }
+
+    static void write(StringBuilder sb, int v) { sb.append(v); }
+
+    static void write(StringBuilder sb, long v) { sb.append(v); }
+
+    static void write(StringBuilder sb, double v) { sb.append(String.format(Locale.ROOT, "%.5f", v)); }
+
+    static void write(StringBuilder sb, boolean v) { sb.append(v); }
+
+    static void write(StringBuilder sb, String v) {
+        sb.append('"');
+        for (int i = 0; i < v.length(); i++) {
+            char c = v.charAt(i);
+            if (c == '"') sb.append("\\\"");
+            else if (c == '\\') sb.append("\\\\");
+            else if (c == '\n') sb.append("\\n");
+            else if (c == '\r') sb.append("\\r");
+            else if (c == '\t') sb.append("\\t");
+            else sb.append(c);
+        }
+        sb.append('"');
+    }
+`
+
+var javaBoxed = map[string]string{Int: "Integer", Long: "Long", Double: "Double", Bool: "Boolean", String: "String"}
+
+// javaReader 读取类型 t 的方法名，如 readInt、readInt1、readString2
+func javaReader(t Type) string {
+	name := "read" + strings.ToUpper(t.Base[:1]) + t.Base[1:]
+	if t.Dim > 0 {
+		name += fmt.Sprint(t.Dim)
+	}
+	return name
+}
+
+func javaDriver(sig *Signature) string {
+	var b strings.Builder
+	b.WriteString(javaDriverHeader)
+
+	// 按需生成数组的读写方法（二维数组依赖一维）
+	readers := make(map[string]bool)
+	for _, t := range sig.paramTypes() {
+		for d := t.Dim; d > 0; d-- {
+			elem := Type{Base: t.Base, Dim: d}
+			if readers[elem.String()] {
+				continue
+			}
+			readers[elem.String()] = true
+			inner := Type{Base: t.Base, Dim: d - 1}
+			boxed := javaType(inner)
+			if inner.Dim == 0 {
+				boxed = javaBoxed[t.Base]
+			}
+			fmt.Fprintf(&b, `
+    static %s %s() {
+        ArrayList<%s> xs = new ArrayList<>();
+        expect('[');
+        if (!accept(']')) {
+            do {
+                xs.add(%s());
+            } while (accept(','));
+            expect(']');
+        }
+        %s a = new %s[xs.size()]%s;
+        for (int i = 0; i < a.length; i++) a[i] = xs.get(i);
+        return a;
+    }
+`, javaType(elem), javaReader(elem), boxed, javaReader(inner), javaType(elem), javaType(Type{Base: t.Base}), strings.Repeat("[]", d-1))
+		}
+	}
+	ret := sig.returnType()
+	for d := 1; d <= ret.Dim; d++ {
+		fmt.Fprintf(&b, `
+    static void write(StringBuilder sb, %s v) {
+        sb.append('[');
+        for (int i = 0; i < v.length; i++) {
+            if (i > 0) sb.append(',');
+            write(sb, v[i]);
+        }
+        sb.append(']');
+    }
+`, javaType(Type{Base: ret.Base, Dim: d}))
+	}
+
+	b.WriteString(`
+    public static void main(String[] args) throws IOException {
+        in = new String(System.in.readAllBytes(), StandardCharsets.UTF_8);
+`)
+	args := make([]string, len(sig.Params))
+	for i, t := range sig.paramTypes() {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "        %s %s = %s();\n", javaType(t), args[i], javaReader(t))
+	}
+	fmt.Fprintf(&b, `        %s res = new Solution().%s(%s);
+        StringBuilder sb = new StringBuilder();
+        write(sb, res);
+        System.out.println(sb);
+    }
+}
+`, javaType(ret), sig.Function, strings.Join(args, ", "))
+	return b.String()
+}
+
+func pythonDriver(sig *Signature) string {
+	types := make([]string, len(sig.Params))
+	for i, t := range sig.paramTypes() {
+		types[i] = fmt.Sprintf("(%q, %d)", t.Base, t.Dim)
+	}
+	ret := sig.returnType()
+	return fmt.Sprintf(`from solution import *
+
+import json as _harness_json
+import sys as _harness_sys
+
+
+def _harness_convert(v, base, dim):
+    if dim > 0:
+        return [_harness_convert(x, base, dim - 1) for x in v]
+    if base == "double":
+        return float(v)
+    if base in ("int", "long"):
+        return int(v)
+    return v
+
+
+def _harness_format(v, base, dim):
+    if dim > 0:
+        return "[" + ",".join(_harness_format(x, base, dim - 1) for x in v) + "]"
+    if base == "double":
+        return "%%.5f" %% v
+    if base == "bool":
+        return "true" if v else "false"
+    if base == "string":
+        s = v.replace("\\", "\\\\").replace('"', '\\"').replace("\n", "\\n").replace("\r", "\\r").replace("\t", "\\t")
+        return '"' + s + '"'
+    return str(int(v))
+
+
+def _harness_main():
+    data = _harness_sys.stdin.read()
+    decoder = _harness_json.JSONDecoder()
+    pos = 0
+    args = []
+    for base, dim in [%s]:
+        while pos < len(data) and data[pos].isspace():
+            pos += 1
+        value, pos = decoder.raw_decode(data, pos)
+        args.append(_harness_convert(value, base, dim))
+    result = Solution().%s(*args)
+    _harness_sys.stdout.write(_harness_format(result, %q, %d) + "\n")
+
+
+_harness_main()
+`, strings.Join(types, ", "), sig.Function, ret.Base, ret.Dim)
+}
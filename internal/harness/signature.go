@@ -0,0 +1,157 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Signature 函数签名题的函数定义，出题人只需定义一次，各语言的初始代码与驱动程序由此生成
+//
+// 测试输入为每个参数一个 JSON 值（通常一行一个），如 [2,7,11,15] 换行 9；
+// 驱动程序以规范格式输出返回值：无空格的 JSON，浮点数保留 5 位小数
+type Signature struct {
+	Function string  `json:"function"`
+	Params   []Param `json:"params"`
+	Return   string  `json:"return"`
+}
+
+// Param 函数参数
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Type 参数/返回值类型：基础类型加数组维度，如 int[][] 为 {int, 2}
+type Type struct {
+	Base string
+	Dim  int
+}
+
+// 支持的基础类型
+const (
+	Int    = "int"
+	Long   = "long"
+	Double = "double"
+	Bool   = "bool"
+	String = "string"
+)
+
+// maxDim 数组的最大维度
+const maxDim = 2
+
+var baseTypes = map[string]bool{Int: true, Long: true, Double: true, Bool: true, String: true}
+
+// Languages 支持函数签名题的语言
+var Languages = []string{"go", "cpp", "java", "python"}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// reservedNames 各语言的关键字及驱动程序使用的名字，不能作为函数名或参数名
+var reservedNames = wordSet(`
+	break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var
+	auto bool char class delete do double enum explicit extern false float friend inline int long namespace new operator private protected public register short signed sizeof static template this throw true try typedef typename union unsigned using virtual void volatile while
+	abstract assert boolean byte catch extends final finally implements instanceof native null strictfp super synchronized throws transient
+	and as async await del elif except from global in is lambda nonlocal not or pass raise with yield None True False self
+	main Main Solution harness string vector List`)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// ParseType 解析类型字符串，如 int、string[]、long[][]
+func ParseType(s string) (Type, error) {
+	t := Type{Base: strings.TrimSpace(s)}
+	for strings.HasSuffix(t.Base, "[]") {
+		t.Base = strings.TrimSpace(strings.TrimSuffix(t.Base, "[]"))
+		t.Dim++
+	}
+	if !baseTypes[t.Base] {
+		return Type{}, fmt.Errorf("不支持的类型 %q（可用 int/long/double/bool/string 及其一、二维数组）", s)
+	}
+	if t.Dim > maxDim {
+		return Type{}, fmt.Errorf("类型 %q 维度过高，最多 %d 维", s, maxDim)
+	}
+	return t, nil
+}
+
+// String 类型的规范写法
+func (t Type) String() string {
+	return t.Base + strings.Repeat("[]", t.Dim)
+}
+
+// ParseSignature 解析并校验 JSON 格式的函数签名
+func ParseSignature(raw string) (*Signature, error) {
+	var sig Signature
+	if err := json.Unmarshal([]byte(raw), &sig); err != nil {
+		return nil, fmt.Errorf("函数签名格式错误: %w", err)
+	}
+	if err := sig.Validate(); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// Validate 校验函数名、参数名与类型，并将类型写法规范化
+func (sig *Signature) Validate() error {
+	if !identPattern.MatchString(sig.Function) || reservedNames[sig.Function] {
+		return fmt.Errorf("函数名 %q 不合法", sig.Function)
+	}
+	seen := make(map[string]bool, len(sig.Params))
+	for i, p := range sig.Params {
+		if !identPattern.MatchString(p.Name) || reservedNames[p.Name] || p.Name == sig.Function {
+			return fmt.Errorf("参数名 %q 不合法", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("参数名 %q 重复", p.Name)
+		}
+		seen[p.Name] = true
+		t, err := ParseType(p.Type)
+		if err != nil {
+			return fmt.Errorf("参数 %s: %w", p.Name, err)
+		}
+		sig.Params[i].Type = t.String()
+	}
+	t, err := ParseType(sig.Return)
+	if err != nil {
+		return fmt.Errorf("返回值: %w", err)
+	}
+	sig.Return = t.String()
+	return nil
+}
+
+// paramTypes 参数类型列表（签名已通过校验）
+func (sig *Signature) paramTypes() []Type {
+	types := make([]Type, len(sig.Params))
+	for i, p := range sig.Params {
+		types[i], _ = ParseType(p.Type)
+	}
+	return types
+}
+
+// returnType 返回值类型（签名已通过校验）
+func (sig *Signature) returnType() Type {
+	t, _ := ParseType(sig.Return)
+	return t
+}
+
+// Marshal 序列化为存储用的 JSON
+func (sig *Signature) Marshal() string {
+	b, _ := json.Marshal(sig)
+	return string(b)
+}
+
+// SupportsLanguage 函数签名题是否支持该语言
+func SupportsLanguage(language string) bool {
+	for _, lang := range Languages {
+		if lang == language {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package harness
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Starter 生成指定语言的初始代码，用户只需补全函数体
+func Starter(sig *Signature, language string) (string, error) {
+	params := make([]string, len(sig.Params))
+	types := sig.paramTypes()
+	ret := sig.returnType()
+
+	switch language {
+	case "go":
+		for i, p := range sig.Params {
+			params[i] = p.Name + " " + goType(types[i])
+		}
+		return fmt.Sprintf("package main\n\nfunc %s(%s) %s {\n\t\n}\n", sig.Function, strings.Join(params, ", "), goType(ret)), nil
+	case "cpp":
+		for i, p := range sig.Params {
+			t := cppType(types[i])
+			if types[i].Dim > 0 {
+				t += "&"
+			}
+			params[i] = t + " " + p.Name
+		}
+		return fmt.Sprintf("class Solution {\npublic:\n    %s %s(%s) {\n        \n    }\n};\n", cppType(ret), sig.Function, strings.Join(params, ", ")), nil
+	case "java":
+		for i, p := range sig.Params {
+			params[i] = javaType(types[i]) + " " + p.Name
+		}
+		return fmt.Sprintf("class Solution {\n    public %s %s(%s) {\n        \n    }\n}\n", javaType(ret), sig.Function, strings.Join(params, ", ")), nil
+	case "python":
+		params = append([]string{"self"}, params...)
+		for i, p := range sig.Params {
+			params[i+1] = p.Name + ": " + pythonType(types[i])
+		}
+		return fmt.Sprintf("from typing import List\n\n\nclass Solution:\n    def %s(%s) -> %s:\n        pass\n", sig.Function, strings.Join(params, ", "), pythonType(ret)), nil
+	}
+	return "", fmt.Errorf("函数签名题不支持语言 %q", language)
+}
+
+func goType(t Type) string {
+	base := map[string]string{Int: "int", Long: "int64", Double: "float64", Bool: "bool", String: "string"}[t.Base]
+	return strings.Repeat("[]", t.Dim) + base
+}
+
+func cppType(t Type) string {
+	s := map[string]string{Int: "int", Long: "long long", Double: "double", Bool: "bool", String: "string"}[t.Base]
+	for i := 0; i < t.Dim; i++ {
+		s = "vector<" + s + ">"
+	}
+	return s
+}
+
+func javaType(t Type) string {
+	base := map[string]string{Int: "int", Long: "long", Double: "double", Bool: "boolean", String: "String"}[t.Base]
+	return base + strings.Repeat("[]", t.Dim)
+}
+
+func pythonType(t Type) string {
+	s := map[string]string{Int: "int", Long: "int", Double: "float", Bool: "bool", String: "str"}[t.Base]
+	for i := 0; i < t.Dim; i++ {
+		s = "List[" + s + "]"
+	}
+	return s
+}
+
+// Starters 生成全部支持语言的初始代码
+func Starters(sig *Signature) map[string]string {
+	starters := make(map[string]string, len(Languages))
+	for _, lang := range Languages {
+		starters[lang], _ = Starter(sig, lang)
+	}
+	return starters
+}
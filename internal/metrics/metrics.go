@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "patreon_oj"
+
+// 评测队列
+var (
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "submission_queue_depth",
+		Help:      "等待评测的提交数量",
+	})
+	QueueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "submission_queue_wait_seconds",
+		Help:      "提交从入队到开始评测的等待时间",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"priority"})
+)
+
+// 评测执行
+var (
+	JudgeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "judge_duration_seconds",
+		Help:      "单次提交的评测耗时（编译+运行全部用例）",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"backend", "language"})
+	CompileFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "compile_failures_total",
+		Help:      "编译失败次数",
+	}, []string{"language"})
+	Verdicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verdicts_total",
+		Help:      "按语言统计的提交最终判定结果",
+	}, []string{"language", "verdict"})
+	ResultCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "judge_result_cache_hits_total",
+		Help:      "复用已有评测结果（未运行沙箱）的提交数",
+	})
+	SystemErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "judge_system_errors_total",
+		Help:      "评测系统错误次数（评测机、沙箱、存储等故障）",
+	}, []string{"kind"})
+)
+
+// 外部依赖
+var (
+	OSSDownloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "oss_download_bytes_total",
+		Help:      "从 OSS 下载的字节数",
+	})
+	OSSDownloadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "oss_download_duration_seconds",
+		Help:      "OSS 对象下载耗时",
+		Buckets:   prometheus.DefBuckets,
+	})
+	Neo4jDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "neo4j_duration_seconds",
+		Help:      "Neo4j 调用耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+	Neo4jErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "neo4j_errors_total",
+		Help:      "Neo4j 调用失败次数",
+	}, []string{"operation"})
+	AIDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI 接口调用耗时",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+	})
+	AIFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_request_failures_total",
+		Help:      "AI 接口调用失败次数",
+	})
+)
+
+// HTTPDuration HTTP 请求耗时，route 为 Gin 注册的路由模板
+var HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "HTTP 请求耗时",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// ObserveNeo4j 记录一次 Neo4j 调用的耗时与结果
+func ObserveNeo4j(operation string, start time.Time, err error) {
+	Neo4jDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		Neo4jErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// GinMiddleware 按路由模板统计请求耗时；SSE 等长连接不计入
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		if c.Writer.Header().Get("Content-Type") == "text/event-stream" {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // 避免未注册路径导致标签基数膨胀
+		}
+		HTTPDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler 返回 /metrics 的处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Clarification 比赛答疑：选手私下提问，裁判私下回复或公开给全体选手；裁判也可以直接发布公告
+type Clarification struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ContestID uint   `gorm:"index" json:"contest_id"`
+	Label     string `gorm:"type:varchar(8)" json:"label"`          // 题号，空表示关于比赛整体
+	UserID    string `gorm:"type:varchar(64);index" json:"user_id"` // 提问者，公告为发布的裁判
+	Content   string `gorm:"type:text" json:"content"`              // 问题，公告为空
+
+	Answer     string     `gorm:"type:text" json:"answer"`
+	AnsweredBy string     `gorm:"type:varchar(64)" json:"answered_by,omitempty"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+	Public     bool       `gorm:"default:false;index" json:"public"` // 是否对全体选手可见
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"`
+}
+
+// 答疑操作
+const (
+	ClarificationAsk       = "ask"       // 选手提问
+	ClarificationAnswer    = "answer"    // 私下回复
+	ClarificationBroadcast = "broadcast" // 回复并公开
+	ClarificationAnnounce  = "announce"  // 裁判发布公告
+)
+
+// ClarificationLog 答疑操作记录，供管理员审计
+type ClarificationLog struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	ClarificationID uint      `gorm:"index" json:"clarification_id"`
+	ContestID       uint      `gorm:"index" json:"contest_id"`
+	Actor           string    `gorm:"type:varchar(64)" json:"actor"`
+	Action          string    `gorm:"type:varchar(16)" json:"action"`
+	Detail          string    `gorm:"type:text" json:"detail"` // 提问内容或回复内容
+	CreatedAt       time.Time `json:"created_at"`
+}
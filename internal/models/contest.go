@@ -0,0 +1,183 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Contest 比赛：在 [StartTime, EndTime) 内开放提交，题目在开始前对普通用户隐藏
+type Contest struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Title       string    `gorm:"not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	StartTime   time.Time `gorm:"index" json:"start_time"`
+	EndTime     time.Time `gorm:"index" json:"end_time"`
+
+	// 赛制：icpc 按通过题数与罚时排名；oi 比赛中只评测样例且不公布结果，结束后对每题最后一次提交完整评测；
+	// ioi 实时完整评测并公布得分，每个子任务取所有提交中的最高分
+	Rule          string     `gorm:"type:varchar(8);default:icpc" json:"rule"`
+	FinalJudgedAt *time.Time `json:"final_judged_at,omitempty"` // oi 赛制开始最终评测的时间
+
+	Visibility   string `gorm:"type:varchar(16);default:public" json:"visibility"` // public / private（仅报名用户可见）
+	Registration string `gorm:"type:varchar(16);default:open" json:"registration"` // open / password / closed（仅管理员添加）
+	Password     string `gorm:"type:varchar(64)" json:"-"`                         // registration 为 password 时的报名密码
+
+	// 封榜：结束前 FreezeMinutes 分钟起选手只能看到封榜前的结果，0 表示不封榜；Unfrozen 表示已滚榜完毕或解除封榜
+	FreezeMinutes int  `gorm:"default:0" json:"freeze_minutes"`
+	Unfrozen      bool `gorm:"default:false" json:"unfrozen"`
+
+	// 计分比赛：结束后由管理员按最终榜单更新 rating，RatedAt 为更新时间
+	Rated   bool       `gorm:"default:false" json:"rated"`
+	RatedAt *time.Time `json:"rated_at,omitempty"`
+
+	CreatedBy string `gorm:"size:36" json:"created_by"`
+
+	Problems []ContestProblem `gorm:"foreignKey:ContestID" json:"problems,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ContestProblem 比赛中的题目，Label 为题号（A、B、C…）
+type ContestProblem struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ContestID  uint   `gorm:"uniqueIndex:idx_contest_label" json:"contest_id"`
+	Label      string `gorm:"type:varchar(8);uniqueIndex:idx_contest_label" json:"label"`
+	QuestionID int    `gorm:"index" json:"question_id"`
+}
+
+// ContestRegistration 比赛报名记录
+type ContestRegistration struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ContestID uint      `gorm:"uniqueIndex:idx_contest_user" json:"contest_id"`
+	UserID    string    `gorm:"type:varchar(64);uniqueIndex:idx_contest_user" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ContestReveal 滚榜时已公布的格子（选手 × 题目）
+type ContestReveal struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ContestID  uint      `gorm:"uniqueIndex:idx_contest_reveal" json:"contest_id"`
+	UserID     string    `gorm:"type:varchar(64);uniqueIndex:idx_contest_reveal" json:"user_id"`
+	QuestionID int       `gorm:"uniqueIndex:idx_contest_reveal" json:"question_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VirtualParticipation 虚拟参赛：比赛结束后用户按原比赛时长独立计时参赛，每人每场一次
+type VirtualParticipation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ContestID uint      `gorm:"uniqueIndex:idx_contest_virtual" json:"contest_id"`
+	UserID    string    `gorm:"type:varchar(64);uniqueIndex:idx_contest_virtual" json:"user_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Running 虚拟参赛在 now 时刻是否进行中
+func (v *VirtualParticipation) Running(now time.Time) bool {
+	return !now.Before(v.StartTime) && now.Before(v.EndTime)
+}
+
+// 赛制
+const (
+	ContestRuleICPC = "icpc"
+	ContestRuleOI   = "oi"
+	ContestRuleIOI  = "ioi"
+)
+
+// 比赛可见性
+const (
+	ContestPublic  = "public"
+	ContestPrivate = "private"
+)
+
+// 比赛报名方式
+const (
+	RegistrationOpen     = "open"
+	RegistrationPassword = "password"
+	RegistrationClosed   = "closed"
+)
+
+// 比赛状态
+const (
+	ContestUpcoming = "upcoming"
+	ContestRunning  = "running"
+	ContestEnded    = "ended"
+)
+
+// State 比赛在 now 时刻的状态
+func (c *Contest) State(now time.Time) string {
+	switch {
+	case now.Before(c.StartTime):
+		return ContestUpcoming
+	case now.Before(c.EndTime):
+		return ContestRunning
+	default:
+		return ContestEnded
+	}
+}
+
+// FreezeTime 封榜时间，不封榜时返回零值
+func (c *Contest) FreezeTime() time.Time {
+	if c.FreezeMinutes <= 0 {
+		return time.Time{}
+	}
+	return c.EndTime.Add(-time.Duration(c.FreezeMinutes) * time.Minute)
+}
+
+// IsFrozen 榜单是否处于封榜状态（到达封榜时间且尚未解除）
+func (c *Contest) IsFrozen(now time.Time) bool {
+	freeze := c.FreezeTime()
+	return !freeze.IsZero() && !c.Unfrozen && !now.Before(freeze)
+}
+
+// ResultsHidden 是否对选手隐藏评测结果与榜单（oi 赛制在最终评测开始前）
+func (c *Contest) ResultsHidden() bool {
+	return c.Rule == ContestRuleOI && c.FinalJudgedAt == nil
+}
+
+// IsRegistered 用户是否已报名比赛
+func IsRegistered(db *gorm.DB, contestID uint, userID string) bool {
+	var count int64
+	db.Model(&ContestRegistration{}).Where("contest_id = ? AND user_id = ?", contestID, userID).Count(&count)
+	return count > 0
+}
+
+// UnstartedContestQuestions 尚未开始的比赛中的题目 ID 子查询，用于对普通用户隐藏这些题目
+func UnstartedContestQuestions(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Model(&ContestProblem{}).
+		Select("contest_problems.question_id").
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("contests.start_time > ?", now)
+}
+
+// InUnstartedContest 题目是否属于尚未开始的比赛
+func InUnstartedContest(db *gorm.DB, questionID int, now time.Time) bool {
+	var count int64
+	db.Model(&ContestProblem{}).
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("contest_problems.question_id = ? AND contests.start_time > ?", questionID, now).
+		Count(&count)
+	return count > 0
+}
+
+// InUnfinishedContest 题目是否属于尚未结束的比赛
+func InUnfinishedContest(db *gorm.DB, questionID int, now time.Time) bool {
+	var count int64
+	db.Model(&ContestProblem{}).
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("contest_problems.question_id = ? AND contests.end_time > ?", questionID, now).
+		Count(&count)
+	return count > 0
+}
+
+// InRunningOIContest 题目是否属于正在进行的 oi 赛制比赛
+func InRunningOIContest(db *gorm.DB, questionID int, now time.Time) bool {
+	var count int64
+	db.Model(&ContestProblem{}).
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("contest_problems.question_id = ? AND contests.rule = ? AND contests.start_time <= ? AND contests.end_time > ?", questionID, ContestRuleOI, now, now).
+		Count(&count)
+	return count > 0
+}
@@ -102,6 +102,22 @@ func AutoMigrate() error {
 		&UserSolvedQuestion{},
 		&UserSkillMastery{},
 		&OjOverView{},
+		&RateLimitBucket{},
+		&PlagiarismReport{},
+		&ReferenceSolution{},
+		&Generator{},
+		&GeneratorScript{},
+		&InputValidator{},
+		&Hack{},
+		&Contest{},
+		&ContestProblem{},
+		&ContestRegistration{},
+		&ContestReveal{},
+		&VirtualParticipation{},
+		&Clarification{},
+		&ClarificationLog{},
+		&RatingChange{},
+		&UserRating{},
 		// 如果有其他模型，在这里添加
 	)
 	if err != nil {
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Generator 题目的数据生成器程序，在沙箱中运行，stdout 即生成的输入文件
+// 生成器必须只依赖命令行参数（含随机种子），保证重复运行结果一致
+type Generator struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID int    `gorm:"uniqueIndex:idx_generator_question_name" json:"question_id"`
+	Name       string `gorm:"type:varchar(64);uniqueIndex:idx_generator_question_name" json:"name"` // 在生成脚本中引用的名字
+	Language   string `gorm:"type:varchar(32)" json:"language"`
+	Code       string `gorm:"type:text" json:"code"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GeneratorScript 生成脚本，每次修改脚本或其引用的生成器都保存为新版本
+// 每行一次生成器调用：`<生成器名> [参数...]`，第 i 行生成 problems/<题号>/gen/<i>.in；# 开头为注释
+type GeneratorScript struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID int    `gorm:"uniqueIndex:idx_generator_script_version" json:"question_id"`
+	Version    int    `gorm:"uniqueIndex:idx_generator_script_version" json:"version"`
+	Content    string `gorm:"type:text" json:"content"`
+	Comment    string `json:"comment"`
+	CreatedBy  string `gorm:"type:varchar(64)" json:"created_by"`
+
+	// 保存该版本时脚本引用的生成器快照（JSON 数组），按该版本生成时使用快照中的代码，之后修改生成器不影响旧版本
+	Generators string `gorm:"type:longtext" json:"-"`
+
+	// 最近一次按该版本生成的结果；Checksums 为各输入文件的 sha256（JSON 数组），用于检查可复现性
+	GeneratedAt *time.Time `json:"generated_at"`
+	CaseCount   int        `json:"case_count"`
+	Checksums   string     `gorm:"type:text" json:"checksums"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Hack 用户用自己构造的输入挑战他人已通过的提交
+type Hack struct {
+	ID                 uint   `gorm:"primaryKey" json:"id"`
+	QuestionID         int    `gorm:"index" json:"question_id"`
+	HackerID           string `gorm:"type:varchar(64);index" json:"hacker_id"`
+	TargetSubmissionID string `gorm:"type:varchar(64);index" json:"target_submission_id"`
+	TargetUserID       string `gorm:"type:varchar(64);index" json:"target_user_id"`
+
+	Input          string `gorm:"type:longtext" json:"input"`
+	ExpectedOutput string `gorm:"type:longtext" json:"expected_output"` // 主参考解的输出
+	ActualOutput   string `gorm:"type:text" json:"actual_output"`       // 目标提交的输出（已截断）
+
+	Status  string `gorm:"type:varchar(16);index" json:"status"`
+	Verdict string `gorm:"type:varchar(8)" json:"verdict"` // 目标提交在该输入上的结论
+	Message string `gorm:"type:text" json:"message"`
+
+	TestCaseID *uint `json:"test_case_id"` // 加入测试数据后对应的测试用例
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Hack 状态
+const (
+	HackPending = "pending" // 等待执行
+	HackRunning = "running" // 执行中
+	HackSuccess = "success" // 目标提交未通过该输入
+	HackFailed  = "failed"  // 目标提交通过了该输入
+	HackInvalid = "invalid" // 输入未通过输入校验器
+	HackError   = "error"   // 系统错误（如参考解运行失败）
+)
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InputValidator 题目的输入校验器（testlib 风格）
+// 从 stdin 读取一个测试输入，合法时以 0 退出，否则非 0 退出并在 stderr 中说明原因
+type InputValidator struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID int    `gorm:"uniqueIndex" json:"question_id"`
+	Language   string `gorm:"type:varchar(32)" json:"language"`
+	Code       string `gorm:"type:text" json:"code"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 测试输入校验状态（空表示未校验或题目没有校验器）
+const (
+	InputValid   = "valid"
+	InputInvalid = "invalid"
+)
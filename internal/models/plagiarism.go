@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// PlagiarismReport 查重报告：scope 为 problem 时比对 QuestionID 的提交，为 contest 时比对 ContestID 比赛中各题的提交
+type PlagiarismReport struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	Scope           string  `gorm:"type:varchar(16);index" json:"scope"` // problem / contest
+	QuestionID      int     `gorm:"index" json:"question_id"`
+	ContestID       uint    `gorm:"index" json:"contest_id,omitempty"`
+	Threshold       float64 `json:"threshold"` // 相似度不低于该值的代码对计入报告并参与聚类
+	Status          string  `gorm:"type:varchar(16)" json:"status"`
+	ErrorMsg        string  `gorm:"type:text" json:"error_msg,omitempty"`
+	SubmissionCount int     `json:"submission_count"`
+	PairCount       int     `json:"pair_count"`
+	ExcludedCount   int     `json:"excluded_count"`         // 未参与比对的多文件提交数
+	Excluded        string  `gorm:"type:text" json:"-"`     // JSON: []string，未参与比对的多文件提交 ID
+	Pairs           string  `gorm:"type:longtext" json:"-"` // JSON: []PlagiarismPair
+	Clusters        string  `gorm:"type:longtext" json:"-"` // JSON: []PlagiarismCluster
+	CreatedBy       string  `gorm:"size:36" json:"created_by"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 查重报告状态
+const (
+	PlagiarismRunning   = "running"
+	PlagiarismCompleted = "completed"
+	PlagiarismFailed    = "failed"
+)
+
+// 查重范围
+const (
+	PlagiarismScopeProblem = "problem"
+	PlagiarismScopeContest = "contest"
+)
+
+// MatchedRegion 两份代码中相互匹配的行区间
+type MatchedRegion struct {
+	StartLineA int `json:"start_line_a"`
+	EndLineA   int `json:"end_line_a"`
+	StartLineB int `json:"start_line_b"`
+	EndLineB   int `json:"end_line_b"`
+}
+
+// PlagiarismPair 一对相似提交
+type PlagiarismPair struct {
+	QuestionID  int             `json:"question_id"`
+	Language    string          `json:"language"`
+	SubmissionA string          `json:"submission_a"`
+	UserA       string          `json:"user_a"`
+	SubmissionB string          `json:"submission_b"`
+	UserB       string          `json:"user_b"`
+	Similarity  float64         `json:"similarity"`
+	CoverageA   float64         `json:"coverage_a"`
+	CoverageB   float64         `json:"coverage_b"`
+	Regions     []MatchedRegion `json:"regions"`
+}
+
+// PlagiarismCluster 互相相似的一组提交
+type PlagiarismCluster struct {
+	QuestionID    int      `json:"question_id"`
+	Language      string   `json:"language"`
+	Submissions   []string `json:"submissions"`
+	Users         []string `json:"users"`
+	MaxSimilarity float64  `json:"max_similarity"`
+}
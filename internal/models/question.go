@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
 type Question struct {
 	// 数据库主键ID（自增）
 	Id int `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -19,6 +25,18 @@ type Question struct {
 	DataRange   string `gorm:"type:text" json:"data_range"`     // 数据范围
 	TimeLimit   int    `gorm:"default:2000" json:"time_limit"`  // 时间限制（毫秒）
 	MemoryLimit int    `gorm:"default:256" json:"memory_limit"` // 内存限制（MB）
+	OutputLimit int    `gorm:"default:0" json:"output_limit"`   // 输出限制（KB），0 表示按期望输出大小自动计算
+
+	// 文件 IO：为空时使用标准输入/输出，否则从 InputFile 读入、向 OutputFile 写出（如 input.txt / output.txt）
+	InputFile  string `gorm:"type:varchar(64)" json:"input_file"`
+	OutputFile string `gorm:"type:varchar(64)" json:"output_file"`
+
+	// 题型：standard 为标准输入输出，function 为函数签名题（只需实现函数，输入解析与输出由驱动程序完成）
+	ProblemType string `gorm:"type:varchar(16);default:standard" json:"problem_type"`
+	Signature   string `gorm:"type:text" json:"signature"` // 函数签名（JSON，见 harness.Signature），仅 function 题型使用
+
+	// 子任务（JSON，见 Subtask），为空时按通过测试点的比例计 100 分
+	Subtasks string `gorm:"type:text" json:"subtasks"`
 
 	// 元数据
 	Tags string `json:"tags"` // 题目标签（逗号分隔）
@@ -29,7 +47,76 @@ type Question struct {
 
 	// 状态信息
 	Status string `gorm:"default:draft" json:"status"` // 题目状态：draft/published/archived/hidden
+
+	// 参考解校验状态：存在参考解时，必须校验通过才能发布
+	ValidationStatus  string `gorm:"type:varchar(16)" json:"validation_status"` // 空/passed/failed
+	ValidationMessage string `gorm:"type:text" json:"validation_message"`
+
+	// 测试数据版本，测试用例增删改时递增，用于使评测结果缓存失效
+	TestDataVersion int `gorm:"default:1" json:"test_data_version"`
 }
+
+// 题型
+const (
+	ProblemTypeStandard = "standard"
+	ProblemTypeFunction = "function"
+)
+
+// IsFunction 是否为函数签名题
+func (q *Question) IsFunction() bool {
+	return q.ProblemType == ProblemTypeFunction
+}
+
+// Subtask 子任务：所属测试点全部通过才得到 Score 分
+type Subtask struct {
+	ID    int `json:"id"`
+	Score int `json:"score"`
+}
+
+// SubtaskList 解析子任务配置，未配置或格式错误时返回空
+func (q *Question) SubtaskList() []Subtask {
+	var subtasks []Subtask
+	if q.Subtasks == "" || json.Unmarshal([]byte(q.Subtasks), &subtasks) != nil {
+		return nil
+	}
+	return subtasks
+}
+
+// FullScore 题目满分：子任务分值之和，未配置子任务时为 100
+func (q *Question) FullScore() int {
+	subtasks := q.SubtaskList()
+	if len(subtasks) == 0 {
+		return 100
+	}
+	total := 0
+	for _, st := range subtasks {
+		total += st.Score
+	}
+	return total
+}
+
+// 参考解校验状态
+const (
+	ValidationPassed = "passed"
+	ValidationFailed = "failed"
+)
+
+// ResetValidation 测试数据或参考解变更后清除校验结果，需重新校验才能发布
+func ResetValidation(questionID int) error {
+	return DB.Model(&Question{}).Where("id = ?", questionID).
+		Updates(map[string]interface{}{"validation_status": "", "validation_message": ""}).Error
+}
+
+// TestDataChanged 测试数据变更：清除校验结果并递增测试数据版本
+func TestDataChanged(questionID int) error {
+	return DB.Model(&Question{}).Where("id = ?", questionID).
+		Updates(map[string]interface{}{
+			"validation_status":  "",
+			"validation_message": "",
+			"test_data_version":  gorm.Expr("test_data_version + 1"),
+		}).Error
+}
+
 type TestCase struct {
 	ID         uint `gorm:"primaryKey"`
 	QuestionID int  `json:"question_id" gorm:"index"` // 改为int类型，与Question.Id匹配
@@ -45,6 +132,16 @@ type TestCase struct {
 	OutputSize int64  `json:"output_size"`                         // 输出文件大小 (字节)
 
 	IsHidden bool `json:"is_hidden"` // 是否隐藏测试用例
+
+	Subtask int `json:"subtask" gorm:"default:0"` // 所属子任务 ID，0 表示不属于任何子任务
+
+	// 样例信息：样例既用于题面展示，也用于“样例评测”模式
+	IsSample    bool `json:"is_sample" gorm:"index"` // 是否为样例
+	SampleOrder int  `json:"sample_order"`           // 样例展示顺序（升序）
+
+	// 输入校验结果：空/valid/invalid，invalid 时 ValidationMessage 为校验器的输出
+	ValidationStatus  string `gorm:"type:varchar(16)" json:"validation_status"`
+	ValidationMessage string `gorm:"type:text" json:"validation_message"`
 }
 
 func (Question) TableName() string {
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RateLimitBucket 令牌桶状态（rate_limit.store = database 时使用，多实例共享）
+type RateLimitBucket struct {
+	BucketKey  string    `gorm:"primaryKey;size:191"`
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null"` // 上次补充令牌的时间
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_bucket"
+}
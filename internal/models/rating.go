@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// InitialRating 未参加过计分比赛的用户的初始 rating
+const InitialRating = 1500
+
+// RatingChange 一场计分比赛中用户的 rating 变化
+type RatingChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ContestID uint      `gorm:"uniqueIndex:idx_rating_contest_user" json:"contest_id"`
+	UserID    string    `gorm:"type:varchar(64);uniqueIndex:idx_rating_contest_user;index" json:"user_id"`
+	Rank      int       `json:"rank"`
+	OldRating int       `json:"old_rating"`
+	NewRating int       `json:"new_rating"`
+	Delta     int       `json:"delta"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserRating 用户当前 rating，由 RatingChange 按比赛顺序累积得到
+type UserRating struct {
+	UserID    string    `gorm:"primaryKey;type:varchar(64)" json:"user_id"`
+	Rating    int       `gorm:"index" json:"rating"`
+	MaxRating int       `json:"max_rating"`
+	Contests  int       `json:"contests"` // 参加的计分比赛场数
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Tier  string `gorm:"-" json:"tier"`
+	Color string `gorm:"-" json:"color"`
+}
+
+// ratingTiers 段位下限（降序）与颜色
+var ratingTiers = []struct {
+	min   int
+	name  string
+	color string
+}{
+	{3000, "legendary_grandmaster", "#ff0000"},
+	{2600, "international_grandmaster", "#ff0000"},
+	{2400, "grandmaster", "#ff0000"},
+	{2300, "international_master", "#ff8c00"},
+	{2100, "master", "#ff8c00"},
+	{1900, "candidate_master", "#aa00aa"},
+	{1600, "expert", "#0000ff"},
+	{1400, "specialist", "#03a89e"},
+	{1200, "pupil", "#008000"},
+	{0, "newbie", "#808080"},
+}
+
+// RatingTier rating 对应的段位与颜色
+func RatingTier(rating int) (string, string) {
+	for _, t := range ratingTiers {
+		if rating >= t.min {
+			return t.name, t.color
+		}
+	}
+	return ratingTiers[len(ratingTiers)-1].name, ratingTiers[len(ratingTiers)-1].color
+}
+
+// WithTier 填充段位与颜色；未参加过计分比赛时为 unrated
+func (r UserRating) WithTier() UserRating {
+	if r.Contests == 0 {
+		r.Tier, r.Color = "unrated", "#000000"
+		return r
+	}
+	r.Tier, r.Color = RatingTier(r.Rating)
+	return r
+}
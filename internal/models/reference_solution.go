@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ReferenceSolution 题目的参考解
+// Kind 表示该解法预期的评测结果；IsMain 的正确解用于生成标准输出
+type ReferenceSolution struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID int    `gorm:"index" json:"question_id"`
+	Name       string `json:"name"`
+	Language   string `gorm:"type:varchar(32)" json:"language"`
+	Code       string `gorm:"type:text" json:"code"`
+	Kind       string `gorm:"type:varchar(16)" json:"kind"` // correct / tle / wa
+	IsMain     bool   `json:"is_main"`
+
+	// 最近一次校验结果
+	LastVerdict string `gorm:"type:varchar(8)" json:"last_verdict"`
+	LastPassed  bool   `json:"last_passed"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 参考解类型
+const (
+	ReferenceCorrect = "correct" // 应通过全部测试点
+	ReferenceTLE     = "tle"     // 应在某个测试点超时
+	ReferenceWA      = "wa"      // 应在某个测试点答案错误
+)
+
+// ExpectedVerdict 参考解类型对应的预期结论
+func (r ReferenceSolution) ExpectedVerdict() string {
+	switch r.Kind {
+	case ReferenceTLE:
+		return VerdictTimeLimitExceeded
+	case ReferenceWA:
+		return VerdictWrongAnswer
+	default:
+		return VerdictAccepted
+	}
+}
@@ -1,59 +1,141 @@
 package models
 
-import(
- "github.com/google/uuid"
-	 "time"
-   
+import (
+	"github.com/google/uuid"
+	"time"
 )
 
 type Submission struct {
-    ID         string    `json:"id" gorm:"primaryKey"`
-    UserID     string    `json:"user_id" gorm:"index"`
-    QuestionID int       `json:"question_id" gorm:"index"`  // 改为int类型，存储题目的数据库ID
+	ID         string `json:"id" gorm:"primaryKey"`
+	UserID     string `json:"user_id" gorm:"index"`
+	QuestionID int    `json:"question_id" gorm:"index"` // 改为int类型，存储题目的数据库ID
 
-    Language   string    `json:"language" gorm:"type:varchar(32);index"`
-    CodeLength int       `json:"code_length" gorm:"index"`
+	Language   string `json:"language" gorm:"type:varchar(32);index"`
+	CodeLength int    `json:"code_length" gorm:"index"`
 
-    RuntimeMs int64 `json:"runtime_ms"`
-    MemoryKB  int64 `json:"memory_kb"`
+	RuntimeMs int64 `json:"runtime_ms"`
+	MemoryKB  int64 `json:"memory_kb"`
 
-    IsPublic bool `json:"is_public" gorm:"default:true;index"`
+	IsPublic bool `json:"is_public" gorm:"default:true;index"`
 
-    Code      string `json:"code" gorm:"type:text"`
-    Status    string `json:"status"`
-    Results   string `json:"results" gorm:"type:text"` // 改为string类型，存储JSON字符串
-    ErrorCode string `json:"error_code"`                // 错误码
-    ErrorMsg  string `json:"error_msg"`                 // 错误信息
+	// 评测模式：full 为完整评测，sample 为仅评测样例（不计入统计与掌握度）
+	JudgeMode string `json:"judge_mode" gorm:"type:varchar(16);default:full;index"`
 
-    CreatedAt time.Time `json:"created_at"` // 标准GORM创建时间字段
-    UpdatedAt time.Time `json:"updated_at"` // 标准GORM更新时间字段
+	// 比赛提交所属的比赛，0 表示练习提交；Virtual 表示虚拟参赛期间的提交，不计入正式榜单
+	ContestID uint `json:"contest_id,omitempty" gorm:"index;default:0"`
+	Virtual   bool `json:"virtual,omitempty" gorm:"default:false"`
+
+	Code string `json:"code" gorm:"type:text"`
+
+	// 多文件提交：文件存放在 OSS 的 SourceKey 前缀下，Code 为空，CodeLength 为文件总大小
+	FileCount int    `json:"file_count" gorm:"default:0"`
+	SourceKey string `json:"source_key,omitempty" gorm:"type:varchar(255)"`
+
+	Status    string `json:"status"`
+	Verdict   string `json:"verdict" gorm:"type:varchar(8);index"` // 总体评测结论（AC/WA/TLE/MLE/OLE/RE/CE）
+	Results   string `json:"results" gorm:"type:text"`             // 改为string类型，存储JSON字符串
+	ErrorCode string `json:"error_code"`                           // 错误码
+	ErrorMsg  string `json:"error_msg"`                            // 错误信息
+
+	// 完整评测的得分（OI/IOI 赛制使用），SubtaskScores 为各子任务得分（JSON，见 SubtaskScore）
+	Score         int    `json:"score" gorm:"default:0"`
+	SubtaskScores string `json:"subtask_scores,omitempty" gorm:"type:text"`
+
+	// 系统错误自动重试：Attempts 为已失败的评测次数，NextRetryAt 为空表示已停止重试，需管理员处理
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty" gorm:"index"`
+
+	// 评测结果缓存：ResultHash 为代码、语言、测试数据版本与限制的摘要，CachedFrom 非空表示结果复用自该提交
+	ResultHash string `json:"-" gorm:"type:varchar(64);index"`
+	CachedFrom string `json:"cached_from,omitempty" gorm:"type:varchar(64)"`
+
+	// 评测各阶段的时间线（JSON 数组，见 TimelineStage），仅管理员可见
+	Timeline string `json:"-" gorm:"type:text"`
+
+	CreatedAt time.Time `json:"created_at"` // 标准GORM创建时间字段
+	UpdatedAt time.Time `json:"updated_at"` // 标准GORM更新时间字段
+}
+
+// 评测模式
+const (
+	JudgeModeFull   = "full"   // 完整评测
+	JudgeModeSample = "sample" // 仅样例评测
+)
+
+// 评测结论
+const (
+	VerdictAccepted            = "AC"  // 答案正确
+	VerdictWrongAnswer         = "WA"  // 答案错误
+	VerdictTimeLimitExceeded   = "TLE" // 超时
+	VerdictMemoryLimitExceeded = "MLE" // 内存超限
+	VerdictOutputLimitExceeded = "OLE" // 输出超限
+	VerdictRuntimeError        = "RE"  // 运行时错误
+	VerdictCompileError        = "CE"  // 编译错误
+)
+
+// SubtaskScore 子任务得分；未配置子任务时只有一项，ID 为 0
+type SubtaskScore struct {
+	ID    int `json:"id"`
+	Score int `json:"score"`
+	Full  int `json:"full"`
+}
+
+// TimelineStage 评测时间线中的一个阶段
+type TimelineStage struct {
+	Stage      string    `json:"stage"`
+	Case       int       `json:"case,omitempty"` // Stage 为 case 时的测试点序号，从 1 开始
+	Start      time.Time `json:"start"`
+	DurationMs float64   `json:"duration_ms"`
 }
 
 type TestCaseResult struct {
-    Input          string `json:"input"`
-    ExpectedOutput string `json:"expected_output"`
-    ActualOutput   string `json:"actual_output"`
-    IsCorrect      bool   `json:"is_correct"`
-    Runtime        int64  `json:"runtime"` // 毫秒
-    MemoryUsage    int64  `json:"memory_usage"` // KB
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+	ActualOutput   string `json:"actual_output"`
+	IsCorrect      bool   `json:"is_correct"`
+	Verdict        string `json:"verdict"`
+	Runtime        int64  `json:"runtime"`      // 毫秒
+	MemoryUsage    int64  `json:"memory_usage"` // KB
+
+	Stderr   string `json:"stderr,omitempty"` // 标准错误输出（已截断）
+	ExitCode int    `json:"exit_code"`        // 进程退出码
+	Signal   string `json:"signal,omitempty"` // 终止进程的信号，如 SIGSEGV
+	IsHidden bool   `json:"is_hidden"`        // 是否为隐藏测试用例
+
+	Subtask int `json:"subtask,omitempty"` // 所属子任务 ID，用于计算得分
 }
 
 func NewSubmission(userID string, questionID int, code string, language string) *Submission {
-    return &Submission{
-        ID:         uuid.New().String(),
-        UserID:     userID,
-        QuestionID: questionID, // 现在接收int类型的题目ID
-
-        Language:   language,
-        CodeLength: len(code),
-        RuntimeMs:  0,
-        MemoryKB:   0,
-        IsPublic:   true,
-
-        Code:     code,
-        Status:   "pending",
-        Results:  "", // 初始化为空字符串
-        ErrorCode: "", // 初始化为空
-        ErrorMsg:  "", // 初始化为空
-    }
-}
\ No newline at end of file
+	return &Submission{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		QuestionID: questionID, // 现在接收int类型的题目ID
+
+		Language:   language,
+		CodeLength: len(code),
+		RuntimeMs:  0,
+		MemoryKB:   0,
+		IsPublic:   true,
+		JudgeMode:  JudgeModeFull,
+
+		Code:      code,
+		Status:    "pending",
+		Results:   "", // 初始化为空字符串
+		ErrorCode: "", // 初始化为空
+		ErrorMsg:  "", // 初始化为空
+	}
+}
+
+// OverallVerdict 根据各测试用例结果计算总体结论：全部通过为 AC，否则取第一个未通过用例的结论
+func OverallVerdict(results []TestCaseResult) string {
+	for _, r := range results {
+		if r.IsCorrect {
+			continue
+		}
+		if r.Verdict != "" {
+			return r.Verdict
+		}
+		return VerdictWrongAnswer
+	}
+	return VerdictAccepted
+}
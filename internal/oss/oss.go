@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"dachuang/internal/metrics"
+
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
@@ -90,6 +92,9 @@ func (o *OSS) StatObject(ctx context.Context, bucket, key string) (minio.ObjectI
 
 // GetObjectBytes 从 OSS 获取文件内容为字节数组
 func (o *OSS) GetObjectBytes(ctx context.Context, bucket, key string) ([]byte, error) {
+	start := time.Now()
+	defer func() { metrics.OSSDownloadDuration.Observe(time.Since(start).Seconds()) }()
+
 	obj, err := o.cli.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, err
@@ -97,6 +102,7 @@ func (o *OSS) GetObjectBytes(ctx context.Context, bucket, key string) ([]byte, e
 	defer obj.Close()
 
 	b, err := io.ReadAll(obj)
+	metrics.OSSDownloadBytes.Add(float64(len(b)))
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +223,7 @@ type ObjectInfo struct {
 	Size         int64     `json:"size"`
 	LastModified time.Time `json:"last_modified"`
 	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
 	IsDir        bool      `json:"is_dir"`
 }
 
@@ -237,6 +244,7 @@ func (o *OSS) ListObjectsInfo(ctx context.Context, bucket, prefix string, recurs
 			Size:         object.Size,
 			LastModified: object.LastModified,
 			ContentType:  object.ContentType,
+			ETag:         object.ETag,
 			IsDir:        strings.HasSuffix(object.Key, "/"),
 		})
 	}
@@ -0,0 +1,55 @@
+package plagiarism
+
+import "sort"
+
+// Edge 两份代码（以下标表示）之间的相似度
+type Edge struct {
+	A, B       int
+	Similarity float64
+}
+
+// Cluster 将相似度不低于 threshold 的代码连通成组，只返回包含两个及以上成员的组
+func Cluster(n int, edges []Edge, threshold float64) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+
+	for _, e := range edges {
+		if e.Similarity < threshold {
+			continue
+		}
+		ra, rb := find(e.A), find(e.B)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, members := range groups {
+		if len(members) >= 2 {
+			clusters = append(clusters, members)
+		}
+	}
+	// 大的组排在前面，便于优先审查
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
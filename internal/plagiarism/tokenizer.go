@@ -0,0 +1,199 @@
+package plagiarism
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token 归一化后的词法单元
+// 标识符统一为 "V"、字符串/字符字面量为 "S"、数字为 "N"，关键字与运算符保留原文，
+// 这样改名、改常量、调整空白和注释都不会影响比对结果
+type Token struct {
+	Text string
+	Line int // 所在行，从 1 开始
+}
+
+const (
+	identToken  = "V"
+	stringToken = "S"
+	numberToken = "N"
+)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// keywords 各语言的关键字，保留原文参与比对
+var keywords = map[string]map[string]bool{
+	"go": wordSet(`break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var`),
+	"cpp": wordSet(`auto bool break case char class const continue default delete do double else enum
+		extern float for goto if inline int long namespace new operator private protected public
+		return short signed sizeof static struct switch template this throw try catch typedef typename
+		union unsigned using virtual void volatile while`),
+	"java": wordSet(`abstract boolean break byte case catch char class continue default do double else
+		extends final finally float for if implements import instanceof int interface long new package
+		private protected public return short static super switch this throw throws try void while`),
+	"python": wordSet(`and as assert break class continue def del elif else except finally for from
+		global if import in is lambda nonlocal not or pass raise return try while with yield`),
+}
+
+// operators 多字符运算符，按长度降序匹配
+var operators = []string{
+	"<<=", ">>=", "...", "**=", "//=", "->*", "&^=",
+	"==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "*=", "/=", "%=",
+	"&=", "|=", "^=", "<<", ">>", "->", "::", ":=", "<-", "**", "//", "&^",
+}
+
+// stringPrefixes Python 字符串前缀
+var stringPrefixes = wordSet("r u b f rb br fr rf")
+
+// Tokenize 按语言对源码做词法分析并归一化
+func Tokenize(code, language string) []Token {
+	lang := strings.ToLower(strings.TrimSpace(language))
+	kw := keywords[lang]
+	python := lang == "python"
+
+	src := []rune(code)
+	var tokens []Token
+	line := 1
+	i := 0
+	atLineStart := true
+
+	for i < len(src) {
+		ch := src[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+			atLineStart = true
+			continue
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		}
+
+		startLine := line
+		lineStart := atLineStart
+		atLineStart = false
+
+		switch {
+		// 注释
+		case python && ch == '#':
+			i = skipToLineEnd(src, i)
+		case !python && ch == '#' && lineStart:
+			// C/C++ 预处理指令属于模板代码，不参与比对
+			i = skipToLineEnd(src, i)
+		case !python && ch == '/' && i+1 < len(src) && src[i+1] == '/':
+			i = skipToLineEnd(src, i)
+		case !python && ch == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i < len(src) && !(src[i] == '*' && i+1 < len(src) && src[i+1] == '/') {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+
+		// 字面量
+		case ch == '"' || ch == '\'' || (ch == '`' && lang == "go"):
+			i, line = skipString(src, i, line, python)
+			tokens = append(tokens, Token{Text: stringToken, Line: startLine})
+		case unicode.IsDigit(ch) || (ch == '.' && i+1 < len(src) && unicode.IsDigit(src[i+1])):
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, Token{Text: numberToken, Line: startLine})
+
+		// 标识符与关键字
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_') {
+				i++
+			}
+			word := string(src[start:i])
+			if python && i < len(src) && (src[i] == '"' || src[i] == '\'') && stringPrefixes[strings.ToLower(word)] {
+				i, line = skipString(src, i, line, python)
+				tokens = append(tokens, Token{Text: stringToken, Line: startLine})
+				continue
+			}
+			if kw[word] {
+				tokens = append(tokens, Token{Text: word, Line: startLine})
+			} else {
+				tokens = append(tokens, Token{Text: identToken, Line: startLine})
+			}
+
+		// 运算符与分隔符
+		default:
+			op := string(ch)
+			for _, candidate := range operators {
+				if hasPrefixAt(src, i, candidate) {
+					op = candidate
+					break
+				}
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, Token{Text: op, Line: startLine})
+		}
+	}
+	return tokens
+}
+
+// skipToLineEnd 跳到当前行末尾（不消费换行符）
+func skipToLineEnd(src []rune, i int) int {
+	for i < len(src) && src[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+// skipString 跳过字符串字面量，支持转义、Go 原始字符串与 Python 三引号字符串
+func skipString(src []rune, i, line int, python bool) (int, int) {
+	quote := src[i]
+	if python && hasPrefixAt(src, i, strings.Repeat(string(quote), 3)) {
+		end := strings.Repeat(string(quote), 3)
+		i += 3
+		for i < len(src) && !hasPrefixAt(src, i, end) {
+			if src[i] == '\n' {
+				line++
+			}
+			if src[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		return min(i+3, len(src)), line
+	}
+
+	raw := quote == '`'
+	i++
+	for i < len(src) && src[i] != quote {
+		if src[i] == '\n' {
+			if !raw {
+				break // 未闭合的字符串到行尾为止
+			}
+			line++
+		}
+		if src[i] == '\\' && !raw {
+			i++
+		}
+		i++
+	}
+	return min(i+1, len(src)), line
+}
+
+func hasPrefixAt(src []rune, i int, prefix string) bool {
+	for _, r := range prefix {
+		if i >= len(src) || src[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
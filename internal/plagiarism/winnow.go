@@ -0,0 +1,168 @@
+package plagiarism
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// 默认参数：k-gram 长度与窗口大小（单位均为 token）
+// 任何长度不少于 k+w-1 的公共片段都保证能被检出
+const (
+	DefaultK      = 12
+	DefaultWindow = 8
+)
+
+// Fingerprint 被选中的 k-gram 哈希
+type Fingerprint struct {
+	Hash uint64
+	Pos  int // k-gram 起始 token 下标
+}
+
+// Document 参与比对的一份代码
+type Document struct {
+	ID     string
+	Tokens []Token
+	Prints []Fingerprint
+	k      int
+	w      int
+	index  map[uint64][]int // 哈希 -> 起始 token 下标
+}
+
+// NewDocument 对代码分词并计算指纹
+func NewDocument(id, code, language string, k, w int) *Document {
+	if k <= 0 {
+		k = DefaultK
+	}
+	if w <= 0 {
+		w = DefaultWindow
+	}
+	tokens := Tokenize(code, language)
+	doc := &Document{ID: id, Tokens: tokens, k: k, w: w, index: make(map[uint64][]int)}
+	doc.Prints = Winnow(tokens, k, w)
+	for _, fp := range doc.Prints {
+		doc.index[fp.Hash] = append(doc.index[fp.Hash], fp.Pos)
+	}
+	return doc
+}
+
+// Winnow 计算 token 序列的 winnowing 指纹：每个长度为 w 的窗口取最小哈希（并列取最右）
+func Winnow(tokens []Token, k, w int) []Fingerprint {
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) < k {
+		k = len(tokens) // 过短的代码整体作为一个 k-gram
+	}
+
+	hashes := make([]uint64, len(tokens)-k+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+k] {
+			h.Write([]byte(t.Text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+	if len(hashes) < w {
+		w = len(hashes)
+	}
+
+	var prints []Fingerprint
+	last := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		minPos := start
+		for j := start; j < start+w; j++ {
+			if hashes[j] <= hashes[minPos] {
+				minPos = j
+			}
+		}
+		if minPos != last {
+			prints = append(prints, Fingerprint{Hash: hashes[minPos], Pos: minPos})
+			last = minPos
+		}
+	}
+	return prints
+}
+
+// Region 两份代码中相互匹配的行区间
+type Region struct {
+	StartLineA int `json:"start_line_a"`
+	EndLineA   int `json:"end_line_a"`
+	StartLineB int `json:"start_line_b"`
+	EndLineB   int `json:"end_line_b"`
+}
+
+// Match 两份代码的比对结果
+type Match struct {
+	Similarity float64  // Dice 系数：2|A∩B| / (|A|+|B|)
+	CoverageA  float64  // A 的指纹中出现在 B 里的比例
+	CoverageB  float64  // B 的指纹中出现在 A 里的比例
+	Regions    []Region // 匹配片段
+}
+
+// maxPositionsPerHash 同一哈希在一份代码中出现多次时，最多参与配对的位置数
+const maxPositionsPerHash = 4
+
+// Compare 比较两份代码的指纹
+func Compare(a, b *Document) Match {
+	if len(a.index) == 0 || len(b.index) == 0 {
+		return Match{}
+	}
+
+	common := 0
+	type posPair struct{ a, b int }
+	var pairs []posPair
+	for hash, posA := range a.index {
+		posB, ok := b.index[hash]
+		if !ok {
+			continue
+		}
+		common++
+		for _, pa := range posA[:min(len(posA), maxPositionsPerHash)] {
+			for _, pb := range posB[:min(len(posB), maxPositionsPerHash)] {
+				pairs = append(pairs, posPair{pa, pb})
+			}
+		}
+	}
+
+	m := Match{
+		Similarity: 2 * float64(common) / float64(len(a.index)+len(b.index)),
+		CoverageA:  float64(common) / float64(len(a.index)),
+		CoverageB:  float64(common) / float64(len(b.index)),
+	}
+	if common == 0 {
+		return m
+	}
+
+	// 按 A 中位置排序后合并相邻的匹配，得到连续片段
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+	k := min(a.k, len(a.Tokens), len(b.Tokens))
+	gap := a.w
+	var spans [][4]int // startA, endA, startB, endB（token 下标，左闭右开）
+	for _, p := range pairs {
+		if n := len(spans); n > 0 {
+			s := &spans[n-1]
+			if p.a <= s[1]+gap && p.b >= s[2] && p.b <= s[3]+gap {
+				s[1] = max(s[1], p.a+k)
+				s[3] = max(s[3], p.b+k)
+				continue
+			}
+		}
+		spans = append(spans, [4]int{p.a, p.a + k, p.b, p.b + k})
+	}
+
+	for _, s := range spans {
+		m.Regions = append(m.Regions, Region{
+			StartLineA: a.Tokens[s[0]].Line,
+			EndLineA:   a.Tokens[min(s[1], len(a.Tokens))-1].Line,
+			StartLineB: b.Tokens[s[2]].Line,
+			EndLineB:   b.Tokens[min(s[3], len(b.Tokens))-1].Line,
+		})
+	}
+	return m
+}
@@ -8,6 +8,7 @@ import (
 	"dachuang/internal/Controllers/admin"
 	"dachuang/internal/config"
 	"dachuang/internal/graph"
+	"dachuang/internal/metrics"
 	"dachuang/internal/models"
 	"dachuang/internal/oss"
 	"dachuang/internal/services"
@@ -16,6 +17,10 @@ import (
 )
 
 func RoutersInit(r *gin.Engine, ossClient *oss.OSS, graphService *graph.QuestionGraphService) {
+	// 监控：请求耗时统计需在注册路由之前挂载
+	r.Use(metrics.GinMiddleware())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// 初始化各个控制器
 	userCtrl := admin.NewUserController(models.DB)
 	authCtrl := admin.NewAuthController(models.DB)
@@ -59,7 +64,7 @@ func RoutersInit(r *gin.Engine, ossClient *oss.OSS, graphService *graph.Question
 	// 题目相关路由
 	questionRouter := r.Group("/question")
 	{
-		questionCtrl := admin.NewQuestionController(models.DB)
+		questionCtrl := admin.NewQuestionController(models.DB, ossClient)
 		questionRouter.GET("/", questionCtrl.Index)
 		questionRouter.GET("/new", questionCtrl.GetNewProblems)
 		questionRouter.GET("/id/:question_id", questionCtrl.ShowByQuestionID) // 通过自定义question_id获取单个题目
@@ -67,6 +72,40 @@ func RoutersInit(r *gin.Engine, ossClient *oss.OSS, graphService *graph.Question
 		questionRouter.POST("/", questionCtrl.Store)
 		questionRouter.POST("/:number", questionCtrl.Update) // 改为使用题目编号
 		questionRouter.DELETE("/delete", questionCtrl.DeleteProblem)
+
+		// 参考解与期望输出生成（仅管理员）
+		refCtrl := admin.NewReferenceSolutionController(models.DB, ossClient)
+		questionRouter.GET("/:number/solutions", refCtrl.Index)
+		questionRouter.POST("/:number/solutions", refCtrl.Store)
+		questionRouter.PUT("/:number/solutions/:id", refCtrl.Update)
+		questionRouter.DELETE("/:number/solutions/:id", refCtrl.Delete)
+		questionRouter.POST("/:number/solutions/generate", refCtrl.Generate)
+
+		// 数据生成器与生成脚本（仅管理员）
+		genCtrl := admin.NewGeneratorController(models.DB, ossClient)
+		questionRouter.GET("/:number/generators", genCtrl.Index)
+		questionRouter.POST("/:number/generators", genCtrl.Store)
+		questionRouter.DELETE("/:number/generators/:id", genCtrl.Delete)
+		questionRouter.GET("/:number/generator-scripts", genCtrl.Scripts)
+		questionRouter.POST("/:number/generator-scripts", genCtrl.SaveScript)
+		questionRouter.POST("/:number/generator-scripts/run", genCtrl.Generate)
+
+		// 输入校验器（仅管理员）
+		validatorCtrl := admin.NewInputValidatorController(models.DB, ossClient)
+		questionRouter.GET("/:number/validator", validatorCtrl.Show)
+		questionRouter.PUT("/:number/validator", validatorCtrl.Save)
+		questionRouter.DELETE("/:number/validator", validatorCtrl.Delete)
+		questionRouter.POST("/:number/validator/run", validatorCtrl.Run)
+
+		// 函数签名题（仅管理员）
+		signatureCtrl := admin.NewSignatureController(models.DB)
+		questionRouter.PUT("/:number/signature", signatureCtrl.Save)
+		questionRouter.DELETE("/:number/signature", signatureCtrl.Delete)
+
+		// 子任务与得分（设置仅管理员）
+		subtaskCtrl := admin.NewSubtaskController(models.DB)
+		questionRouter.GET("/:number/subtasks", subtaskCtrl.Show)
+		questionRouter.PUT("/:number/subtasks", subtaskCtrl.Save)
 	}
 
 	// 分类相关路由
@@ -97,7 +136,24 @@ func RoutersInit(r *gin.Engine, ossClient *oss.OSS, graphService *graph.Question
 	submissionRouter := r.Group("/submission")
 	{
 		submissionRouter.POST("/", submissionCtrl.SubmitCode)
+		submissionRouter.POST("/archive", submissionCtrl.SubmitArchive)       // 压缩包提交多文件项目（multipart）
+		submissionRouter.GET("/stream", submissionCtrl.StreamUserSubmissions) // 订阅用户全部提交的评测进度（SSE）
 		submissionRouter.GET("/:id", submissionCtrl.GetSubmissionResult)
+		submissionRouter.GET("/:id/stream", submissionCtrl.StreamSubmission)  // 订阅单个提交的评测进度（SSE）
+		submissionRouter.GET("/:id/files", submissionCtrl.GetSubmissionFiles) // 多文件提交的源文件（本人或管理员）
+		submissionRouter.POST("/:id/cancel", submissionCtrl.CancelSubmission) // 撤回排队中或正在评测的提交（本人或管理员）
+
+		// 评测队列管理（管理员）
+		submissionRouter.GET("/queue", submissionCtrl.ListQueue)            // 按优先级分组查看排队提交
+		submissionRouter.POST("/queue/:id/move", submissionCtrl.MoveQueued) // 调整优先级或提到同级队首
+		submissionRouter.DELETE("/queue/:id", submissionCtrl.CancelQueued)  // 取消排队中或正在评测的提交
+
+		// 系统错误（管理员）
+		submissionRouter.GET("/system-errors", submissionCtrl.ListSystemErrors) // ?stuck=true 只看已停止自动重试的提交
+		submissionRouter.POST("/:id/retry", submissionCtrl.RetrySubmission)     // 立即重新评测
+
+		// 评测耗时（管理员）
+		submissionRouter.GET("/timeline-stats", submissionCtrl.GetTimelineStats) // 最近评测各阶段耗时的分位数
 	}
 
 	// 测试用例相关路由
@@ -114,6 +170,74 @@ func RoutersInit(r *gin.Engine, ossClient *oss.OSS, graphService *graph.Question
 		testCaseRouter.DELETE("/:id", testCaseCtrl.Delete) // 删除测试用例
 	}
 
+	// 代码查重（管理员）
+	plagiarismRouter := r.Group("/plagiarism")
+	{
+		plagiarismCtrl := admin.NewPlagiarismController(models.DB)
+		plagiarismRouter.POST("/question/:number", plagiarismCtrl.RunForQuestion) // 对单题发起查重
+		plagiarismRouter.POST("/contest/:id", plagiarismCtrl.RunForContest)       // 对比赛发起查重
+		plagiarismRouter.GET("/reports", plagiarismCtrl.ListReports)
+		plagiarismRouter.GET("/reports/:id", plagiarismCtrl.ShowReport)
+		plagiarismRouter.GET("/reports/:id/export", plagiarismCtrl.ExportReport) // 导出 CSV
+	}
+
+	// hack 相关路由
+	hackRouter := r.Group("/hack")
+	{
+		hackCtrl := admin.NewHackController(models.DB, ossClient, submissionCtrl)
+		hackRouter.POST("/", hackCtrl.Store)
+		hackRouter.GET("/", hackCtrl.Index)
+		hackRouter.GET("/:id", hackCtrl.Show)
+		hackRouter.POST("/:id/add-test", hackCtrl.AddToTests) // 成功的 hack 加入测试数据并重新评测（管理员）
+	}
+
+	// 比赛相关路由
+	contestCtrl := admin.NewContestController(models.DB, ossClient, submissionCtrl)
+	contestRouter := r.Group("/contest")
+	{
+		contestRouter.GET("/", contestCtrl.Index)
+		contestRouter.GET("/:id", contestCtrl.Show)
+		contestRouter.GET("/:id/problem/:label", contestCtrl.ShowProblem) // 比赛开始后可见
+		contestRouter.POST("/", contestCtrl.Store)                        // 创建比赛（管理员）
+		contestRouter.PUT("/:id", contestCtrl.Update)                     // 更新比赛（管理员）
+		contestRouter.DELETE("/:id", contestCtrl.Delete)                  // 删除比赛（管理员）
+
+		contestRouter.POST("/:id/register", contestCtrl.Register)
+		contestRouter.DELETE("/:id/register", contestCtrl.Unregister)
+		contestRouter.GET("/:id/registrations", contestCtrl.ListRegistrations) // 报名列表（管理员）
+		contestRouter.POST("/:id/registrations", contestCtrl.AddRegistrations) // 为用户报名（管理员）
+
+		contestRouter.GET("/:id/scoreboard", contestCtrl.Scoreboard)   // ICPC 榜单（封榜期间选手看到封榜视图）
+		contestRouter.POST("/:id/resolve", contestCtrl.Resolve)        // 滚榜：公布下一个封榜格子（管理员）
+		contestRouter.POST("/:id/unfreeze", contestCtrl.Unfreeze)      // 解除封榜（管理员）
+		contestRouter.POST("/:id/final-judge", contestCtrl.FinalJudge) // oi 赛制最终评测（管理员）
+
+		contestRouter.POST("/:id/virtual", contestCtrl.StartVirtual)                // 开始虚拟参赛（比赛结束后）
+		contestRouter.GET("/:id/virtual", contestCtrl.ShowVirtual)                  // 当前用户的虚拟参赛
+		contestRouter.GET("/:id/virtual/scoreboard", contestCtrl.VirtualScoreboard) // 虚拟参赛榜单
+
+		// 比赛答疑（回复与公告需裁判或管理员）
+		clarCtrl := admin.NewClarificationController(models.DB, contestCtrl)
+		contestRouter.GET("/:id/clarifications", clarCtrl.Index)               // 答疑列表，轮询时传 since
+		contestRouter.POST("/:id/clarifications", clarCtrl.Ask)                // 选手提问
+		contestRouter.POST("/:id/clarifications/announce", clarCtrl.Announce)  // 发布公告
+		contestRouter.POST("/:id/clarifications/:cid/answer", clarCtrl.Answer) // 回复提问
+		contestRouter.GET("/:id/clarifications/stream", clarCtrl.Stream)       // 答疑通知（SSE）
+		contestRouter.GET("/:id/clarifications/audit", clarCtrl.Audit)         // 答疑操作记录（管理员）
+	}
+
+	// rating 相关路由（更新、撤销与重算需管理员）
+	ratingRouter := r.Group("/rating")
+	{
+		ratingCtrl := admin.NewRatingController(models.DB, contestCtrl)
+		ratingRouter.GET("/", ratingCtrl.Leaderboard)
+		ratingRouter.POST("/recompute", ratingCtrl.Recompute) // 按比赛历史从头重算全部 rating
+		userRouter.GET("/:uuid/rating", ratingCtrl.UserHistory)
+		contestRouter.GET("/:id/rating", ratingCtrl.ContestChanges)
+		contestRouter.POST("/:id/rating", ratingCtrl.Apply)      // 按最终榜单更新 rating
+		contestRouter.DELETE("/:id/rating", ratingCtrl.Rollback) // 撤销该比赛的 rating 变化
+	}
+
 	// 图数据库相关路由
 	if graphService != nil {
 		graphRouter := r.Group("/graph")
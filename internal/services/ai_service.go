@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"dachuang/internal/config"
+	"dachuang/internal/metrics"
 )
 
 // AIService AI服务
@@ -65,11 +66,19 @@ type SkillRelation struct {
 }
 
 // callAI 通用AI调用方法
-func (s *AIService) callAI(ctx context.Context, systemPrompt, userPropmt string) (string, error) {
+func (s *AIService) callAI(ctx context.Context, systemPrompt, userPropmt string) (content string, err error) {
 	if !s.Config.Enabled {
 		return "", fmt.Errorf("AI service is disabled")
 	}
 
+	start := time.Now()
+	defer func() {
+		metrics.AIDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.AIFailures.Inc()
+		}
+	}()
+
 	reqBody := ChatRequest{
 		Model:       s.Config.Model,
 		Temperature: s.Config.Temperature,
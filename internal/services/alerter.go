@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// alertInterval 同一类告警的最小发送间隔，期间的告警只计数，避免故障期间刷屏
+const alertInterval = time.Minute
+
+// Alert 告警内容
+type Alert struct {
+	Event        string    `json:"event"`
+	Kind         string    `json:"kind"`
+	SubmissionID string    `json:"submission_id,omitempty"`
+	Attempts     int       `json:"attempts,omitempty"`
+	Error        string    `json:"error"`
+	Suppressed   int       `json:"suppressed,omitempty"` // 上次发送后被合并的同类告警数
+	Text         string    `json:"text"`
+	Time         time.Time `json:"time"`
+}
+
+// Alerter 向 webhook 发送告警，按 Event+Kind 限流
+type Alerter struct {
+	webhook string
+	client  *http.Client
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+}
+
+// NewAlerter 创建告警器，webhook 为空时只写日志
+func NewAlerter(webhook string) *Alerter {
+	return &Alerter{
+		webhook:    webhook,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// Notify 发送告警，不阻塞调用方
+func (a *Alerter) Notify(alert Alert) {
+	log.Printf("[ALERT] %s", alert.Text)
+	if a == nil || a.webhook == "" {
+		return
+	}
+
+	key := alert.Event + "/" + alert.Kind
+	now := time.Now()
+	a.mu.Lock()
+	if now.Sub(a.lastSent[key]) < alertInterval {
+		a.suppressed[key]++
+		a.mu.Unlock()
+		return
+	}
+	a.lastSent[key] = now
+	alert.Suppressed = a.suppressed[key]
+	a.suppressed[key] = 0
+	a.mu.Unlock()
+
+	if alert.Time.IsZero() {
+		alert.Time = now
+	}
+	go func() {
+		body, _ := json.Marshal(alert)
+		resp, err := a.client.Post(a.webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("发送告警失败: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("发送告警失败: webhook 返回 %d", resp.StatusCode)
+		}
+	}()
+}
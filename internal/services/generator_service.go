@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxGeneratedCases 一个生成脚本最多生成的测试点数
+const maxGeneratedCases = 200
+
+// generatorOutputLimit 单个生成器调用的 stdout 上限（字节）
+const generatorOutputLimit int64 = 64 << 20
+
+// GeneratorCall 生成脚本中的一行调用
+type GeneratorCall struct {
+	Line      int      `json:"line"`
+	Generator string   `json:"generator"`
+	Args      []string `json:"args"`
+}
+
+// ParseGeneratorScript 解析生成脚本，每个非空、非注释行对应一个测试点
+func ParseGeneratorScript(content string) ([]GeneratorCall, error) {
+	var calls []GeneratorCall
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		calls = append(calls, GeneratorCall{Line: i + 1, Generator: fields[0], Args: fields[1:]})
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("生成脚本为空")
+	}
+	if len(calls) > maxGeneratedCases {
+		return nil, fmt.Errorf("生成脚本最多 %d 行调用，当前 %d 行", maxGeneratedCases, len(calls))
+	}
+	return calls, nil
+}
+
+// generatorSnapshot 生成脚本版本中保存的生成器
+type generatorSnapshot struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// SaveGeneratorScript 把生成脚本保存为新版本，同时保存脚本引用的生成器的当前代码
+func (ps *ProblemSetterService) SaveGeneratorScript(questionID int, content, comment, createdBy string) (*models.GeneratorScript, []GeneratorCall, error) {
+	calls, err := ParseGeneratorScript(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(calls))
+	seen := make(map[string]bool)
+	for _, call := range calls {
+		if !seen[call.Generator] {
+			seen[call.Generator] = true
+			names = append(names, call.Generator)
+		}
+	}
+	var generators []models.Generator
+	if err := ps.DB.Where("question_id = ? AND name IN ?", questionID, names).Order("name ASC").Find(&generators).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询生成器失败: %w", err)
+	}
+	found := make(map[string]bool, len(generators))
+	snapshot := make([]generatorSnapshot, 0, len(generators))
+	for _, gen := range generators {
+		found[gen.Name] = true
+		snapshot = append(snapshot, generatorSnapshot{Name: gen.Name, Language: gen.Language, Code: gen.Code})
+	}
+	for _, call := range calls {
+		if !found[call.Generator] {
+			return nil, nil, fmt.Errorf("第 %d 行: 生成器 %s 不存在", call.Line, call.Generator)
+		}
+	}
+	data, _ := json.Marshal(snapshot)
+
+	script := models.GeneratorScript{QuestionID: questionID, Content: content, Comment: comment, CreatedBy: createdBy, Generators: string(data)}
+	err = ps.DB.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.GeneratorScript{}).Where("question_id = ?", questionID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		script.Version = latest + 1
+		return tx.Create(&script).Error
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("保存生成脚本失败: %w", err)
+	}
+	return &script, calls, nil
+}
+
+// GeneratorUpdated 生成器代码变化后，若最新版本的脚本引用了它，按当前代码保存一个新版本并返回
+func (ps *ProblemSetterService) GeneratorUpdated(questionID int, name, createdBy string) (*models.GeneratorScript, error) {
+	var latest models.GeneratorScript
+	err := ps.DB.Where("question_id = ?", questionID).Order("version DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询生成脚本失败: %w", err)
+	}
+	calls, err := ParseGeneratorScript(latest.Content)
+	if err != nil {
+		return nil, nil
+	}
+	for _, call := range calls {
+		if call.Generator == name {
+			script, _, err := ps.SaveGeneratorScript(questionID, latest.Content, fmt.Sprintf("更新生成器 %s", name), createdBy)
+			return script, err
+		}
+	}
+	return nil, nil
+}
+
+// GenerationReport 运行生成脚本的结果
+type GenerationReport struct {
+	ScriptVersion    int                     `json:"script_version"`
+	Cases            int                     `json:"cases"`
+	Removed          int                     `json:"removed"`                     // 上次生成多出、本次删除的测试点数
+	NonDeterministic []int                   `json:"non_deterministic,omitempty"` // 同一版本重复生成但内容不一致的测试点编号
+	Validation       *ValidationReport       `json:"validation"`
+	Inputs           *InputValidationSummary `json:"inputs"` // 输入校验器的检查结果
+}
+
+// RunGenerators 按生成脚本运行生成器，产出 problems/<题号>/gen/<i>.in，
+// 再用主参考解生成输出并登记测试用例（与 OSS 提交同一路径）。version 为 0 时使用最新版本
+func (ps *ProblemSetterService) RunGenerators(ctx context.Context, question *models.Question, version int) (*GenerationReport, error) {
+	if ps.OSSClient == nil || ps.OSSBucket == "" {
+		return nil, fmt.Errorf("OSS 未初始化")
+	}
+
+	var script models.GeneratorScript
+	query := ps.DB.Where("question_id = ?", question.Id)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	if err := query.Order("version DESC").First(&script).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("生成脚本不存在")
+		}
+		return nil, fmt.Errorf("查询生成脚本失败: %w", err)
+	}
+	calls, err := ParseGeneratorScript(script.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var mainCount int64
+	ps.DB.Model(&models.ReferenceSolution{}).Where("question_id = ? AND is_main = ?", question.Id, true).Count(&mainCount)
+	if mainCount == 0 {
+		return nil, fmt.Errorf("题目没有设置主参考解，无法生成输出")
+	}
+
+	inputs, err := ps.runGeneratorCalls(question, &script, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GenerationReport{ScriptVersion: script.Version, Cases: len(inputs)}
+	var previous []string
+	if script.Checksums != "" {
+		_ = json.Unmarshal([]byte(script.Checksums), &previous)
+	}
+	checksums := make([]string, len(inputs))
+	for i, input := range inputs {
+		sum := sha256.Sum256([]byte(input))
+		checksums[i] = hex.EncodeToString(sum[:])
+		if i < len(previous) && previous[i] != checksums[i] {
+			report.NonDeterministic = append(report.NonDeterministic, i+1)
+		}
+
+		key := generatedInputKey(question, i+1)
+		if _, err := ps.OSSClient.UploadFile(ctx, ps.OSSBucket, key, strings.NewReader(input), int64(len(input)), "text/plain"); err != nil {
+			return nil, fmt.Errorf("上传输入文件失败(key=%s): %w", key, err)
+		}
+	}
+
+	removed, err := ps.removeStaleGenerated(ctx, question, len(inputs))
+	if err != nil {
+		return nil, err
+	}
+	report.Removed = removed
+
+	now := time.Now()
+	sums, _ := json.Marshal(checksums)
+	if err := ps.DB.Model(&script).Updates(map[string]interface{}{
+		"generated_at": &now,
+		"case_count":   len(inputs),
+		"checksums":    string(sums),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("保存生成记录失败: %w", err)
+	}
+
+	report.Validation, err = ps.GenerateAndValidate(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	report.Inputs, err = ps.ValidateTestCases(ctx, question.Id)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// runGeneratorCalls 按生成器分组运行脚本中的调用，每个生成器只编译一次，返回按行顺序排列的输入内容
+// 使用脚本版本保存的生成器快照；没有快照的旧版本使用生成器的当前代码
+func (ps *ProblemSetterService) runGeneratorCalls(question *models.Question, script *models.GeneratorScript, calls []GeneratorCall) ([]string, error) {
+	var generators []generatorSnapshot
+	if script.Generators != "" {
+		if err := json.Unmarshal([]byte(script.Generators), &generators); err != nil {
+			return nil, fmt.Errorf("解析生成器快照失败: %w", err)
+		}
+	} else {
+		var current []models.Generator
+		if err := ps.DB.Where("question_id = ?", question.Id).Find(&current).Error; err != nil {
+			return nil, fmt.Errorf("查询生成器失败: %w", err)
+		}
+		for _, gen := range current {
+			generators = append(generators, generatorSnapshot{Name: gen.Name, Language: gen.Language, Code: gen.Code})
+		}
+	}
+	byName := make(map[string]*generatorSnapshot, len(generators))
+	for i := range generators {
+		byName[generators[i].Name] = &generators[i]
+	}
+
+	groups := make(map[string][]int)
+	var order []string
+	for i, call := range calls {
+		if byName[call.Generator] == nil {
+			return nil, fmt.Errorf("第 %d 行: 生成器 %s 不存在", call.Line, call.Generator)
+		}
+		if _, ok := groups[call.Generator]; !ok {
+			order = append(order, call.Generator)
+		}
+		groups[call.Generator] = append(groups[call.Generator], i)
+	}
+
+	inputs := make([]string, len(calls))
+	for _, name := range order {
+		gen := byName[name]
+		idx := groups[name]
+		task := JudgeTask{
+			Code:         gen.Code,
+			Language:     gen.Language,
+			Inputs:       make([]string, len(idx)),
+			OutputLimits: make([]int64, len(idx)),
+			Args:         make([][]string, len(idx)),
+		}
+		if task.Language == "" {
+			task.Language = ps.Judge.DetectLanguage(gen.Code)
+		}
+		for j, i := range idx {
+			task.OutputLimits[j] = generatorOutputLimit
+			task.Args[j] = calls[i].Args
+		}
+
+		results, err := ps.Judge.Run(task)
+		if err != nil {
+			return nil, fmt.Errorf("运行生成器 %s 失败: %w", name, err)
+		}
+		if len(results) != len(idx) {
+			return nil, fmt.Errorf("生成器 %s 运行结果数量不匹配: got=%d want=%d", name, len(results), len(idx))
+		}
+		for j, r := range results {
+			call := calls[idx[j]]
+			if r.Verdict != "" && r.Verdict != models.VerdictAccepted {
+				return nil, fmt.Errorf("第 %d 行: 生成器 %s 运行失败: %s %s", call.Line, name, r.Verdict, r.Stderr)
+			}
+			inputs[idx[j]] = asFileContent(r.ActualOutput)
+		}
+	}
+	return inputs, nil
+}
+
+// removeStaleGenerated 删除上次生成但本次脚本已不再产出的测试点（编号大于 count 的部分）
+func (ps *ProblemSetterService) removeStaleGenerated(ctx context.Context, question *models.Question, count int) (int, error) {
+	var last models.GeneratorScript
+	err := ps.DB.Where("question_id = ? AND generated_at IS NOT NULL", question.Id).
+		Order("generated_at DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询生成记录失败: %w", err)
+	}
+
+	removed := 0
+	for i := count + 1; i <= last.CaseCount; i++ {
+		inKey := generatedInputKey(question, i)
+		outKey := strings.TrimSuffix(inKey, ".in") + ".out"
+		if err := ps.DB.Where("question_id = ? AND input_key = ?", question.Id, inKey).Delete(&models.TestCase{}).Error; err != nil {
+			return removed, fmt.Errorf("删除测试用例失败: %w", err)
+		}
+		_ = ps.OSSClient.DeleteFile(ctx, ps.OSSBucket, inKey)
+		_ = ps.OSSClient.DeleteFile(ctx, ps.OSSBucket, outKey)
+		removed++
+	}
+	return removed, nil
+}
+
+// generatedInputKey 第 i 个生成测试点的输入文件路径，放在单独的 gen/ 目录下，不会覆盖或删除手工上传的测试点
+func generatedInputKey(question *models.Question, i int) string {
+	return fmt.Sprintf("problems/%d/gen/%d.in", question.QuestionNumber, i)
+}
+
+// asFileContent 将程序输出整理为以单个换行结尾的文件内容
+func asFileContent(s string) string {
+	return strings.TrimRight(s, "\r\n") + "\n"
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"dachuang/internal/models"
@@ -41,6 +44,7 @@ type CmdRequest struct {
 	CopyOut       []string           `json:"copyOut,omitempty"`
 	CopyOutCached []string           `json:"copyOutCached,omitempty"`
 	CopyOutDir    string             `json:"copyOutDir,omitempty"`
+	CopyOutMax    uint64             `json:"copyOutMax,omitempty"` // byte
 }
 
 // CmdFile 文件定义
@@ -65,30 +69,37 @@ type CmdResponse struct {
 }
 
 // Run 执行判定
-func (c *GoJudgeClient) Run(code string, language string, inputs []string, timeLimitMs int64, memoryLimitMB int64) ([]models.TestCaseResult, error) {
+func (c *GoJudgeClient) Run(task JudgeTask) ([]models.TestCaseResult, error) {
 	// 转换限制单位
-	cpuLimitNs := uint64(timeLimitMs) * 1_000_000
+	cpuLimitNs := uint64(task.TimeLimitMs) * 1_000_000
 	clockLimitNs := cpuLimitNs * 3 // 给多一点墙上时间，防止IO等导致超时
-	memoryLimitByte := uint64(memoryLimitMB) * 1024 * 1024
+	memoryLimitByte := uint64(task.MemoryLimitMB) * 1024 * 1024
+
+	if task.isProject() {
+		return c.runProject(task, cpuLimitNs, clockLimitNs, memoryLimitByte)
+	}
 
 	// 针对不同语言的策略：
-	switch language {
+	switch task.Language {
 	case "cpp", "go":
 		// 需要编译的语言
-		return c.runCompiledLanguage(code, language, inputs, cpuLimitNs, clockLimitNs, memoryLimitByte)
+		return c.runCompiledLanguage(task, cpuLimitNs, clockLimitNs, memoryLimitByte)
 	case "python":
 		// 解释型语言
-		return c.runInterpretedLanguage(code, language, inputs, cpuLimitNs, clockLimitNs, memoryLimitByte)
+		return c.runInterpretedLanguage(task, cpuLimitNs, clockLimitNs, memoryLimitByte)
 	case "java":
-		return c.runJava(code, inputs, cpuLimitNs, clockLimitNs, memoryLimitByte)
+		return c.runJava(task, cpuLimitNs, clockLimitNs, memoryLimitByte)
 	default:
-		return nil, fmt.Errorf("unsupported language for go-judge: %s", language)
+		return nil, userError("unsupported language for go-judge: %s", task.Language)
 	}
 }
 
 // runCompiledLanguage 处理编译型语言 (C++, Go)
-func (c *GoJudgeClient) runCompiledLanguage(code, language string, inputs []string, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+func (c *GoJudgeClient) runCompiledLanguage(task JudgeTask, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+	code, language, inputs := task.Code, task.Language, task.Inputs
+
 	// 步骤 1: 编译
+	task.stage(StageCompiling)
 	// 构造编译请求
 	var compileCmd CmdRequest
 	var srcName, exeName string
@@ -140,14 +151,17 @@ func (c *GoJudgeClient) runCompiledLanguage(code, language string, inputs []stri
 	compileReqBody := map[string]interface{}{
 		"cmd": []CmdRequest{compileCmd},
 	}
-	compileResps, err := c.doRequest(compileReqBody)
+	compileResps, err := c.doRequest(task.context(), compileReqBody)
 	if err != nil {
 		return nil, fmt.Errorf("compile request failed: %w", err)
 	}
 
 	compileResult := compileResps[0]
+	if compileResult.Status == "Internal Error" {
+		return nil, fmt.Errorf("compile internal error: %s", compileResult.Error)
+	}
 	if compileResult.Status != "Accepted" {
-		return nil, fmt.Errorf("Compile Error: %s %s", compileResult.Status, compileResult.Error)
+		return compileErrorResults(inputs, compileResult), nil
 	}
 
 	// 修正编译请求，使用 copyOutCached
@@ -158,33 +172,34 @@ func (c *GoJudgeClient) runCompiledLanguage(code, language string, inputs []stri
 	compileReqBody = map[string]interface{}{
 		"cmd": []CmdRequest{compileCmd},
 	}
-	compileResps, err = c.doRequest(compileReqBody)
+	compileResps, err = c.doRequest(task.context(), compileReqBody)
 	if err != nil {
 		return nil, err
 	}
 
 	if compileResps[0].Status != "Accepted" {
-		// 错误处理... 简化
-		return getAllErrorResult(inputs, "Compile Error"), nil
+		return compileErrorResults(inputs, compileResps[0]), nil
 	}
 
 	exeFileId := compileResps[0].FileIds[exeName]
 	if exeFileId == "" {
 		return nil, fmt.Errorf("compile success but no executable fileId returned")
 	}
+	defer c.deleteFile(exeFileId)
 
 	// 步骤 2: 运行
+	task.stage(StageRunning)
 	// 构造批量运行请求
 	var runCmds []CmdRequest
-	for _, input := range inputs {
+	for i, input := range inputs {
 		inputContent := input
 		runCmd := CmdRequest{
-			Args: []string{"./" + exeName},
+			Args: append([]string{"./" + exeName}, task.args(i)...),
 			Env:  []string{defaultEnv},
 			Files: []*CmdFile{
-				{Content: &inputContent},     // stdin
-				{Name: "stdout", Max: 10240}, // stdout (limit 10KB)
-				{Name: "stderr", Max: 10240}, // stderr
+				{Content: &inputContent},                          // stdin
+				{Name: "stdout", Max: task.outputLimit(i, 0) + 1}, // 多留 1 字节用于判断是否超出输出上限
+				{Name: "stderr", Max: 10240},                      // stderr
 			},
 			CopyIn: map[string]CmdFile{
 				exeName: {FileID: &exeFileId}, // 使用之前的 fileId
@@ -194,13 +209,14 @@ func (c *GoJudgeClient) runCompiledLanguage(code, language string, inputs []stri
 			MemoryLimit: memoryLimit,
 			ProcLimit:   50,
 		}
+		withFileIO(&runCmd, &task, input, task.outputLimit(i, 0))
 		runCmds = append(runCmds, runCmd)
 	}
 
 	runReqBody := map[string]interface{}{
 		"cmd": runCmds,
 	}
-	runResps, err := c.doRequest(runReqBody)
+	runResps, err := c.doRequest(task.context(), runReqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -208,26 +224,30 @@ func (c *GoJudgeClient) runCompiledLanguage(code, language string, inputs []stri
 	// 转换结果
 	results := make([]models.TestCaseResult, len(inputs))
 	for i, resp := range runResps {
-		results[i] = parseResult(resp, inputs[i])
+		results[i] = parseResult(resp, inputs[i], task.outputLimit(i, 0), task.OutputFile)
+		task.caseDone(i, results[i])
 	}
 
 	return results, nil
 }
 
 // runInterpretedLanguage 处理解释型语言 (Python)
-func (c *GoJudgeClient) runInterpretedLanguage(code, language string, inputs []string, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+func (c *GoJudgeClient) runInterpretedLanguage(task JudgeTask, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+	code, inputs := task.Code, task.Inputs
+
 	defaultEnv := "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
 
+	task.stage(StageRunning)
 	var runCmds []CmdRequest
-	for _, input := range inputs {
+	for i, input := range inputs {
 		inputContent := input
 		codeRef := code // copy locally
 		runCmd := CmdRequest{
-			Args: []string{"python3", "main.py"},
+			Args: append([]string{"python3", "main.py"}, task.args(i)...),
 			Env:  []string{defaultEnv, "PYTHONIOENCODING=utf-8"}, // Add encoding for safety
 			Files: []*CmdFile{
 				{Content: &inputContent},
-				{Name: "stdout", Max: 10240},
+				{Name: "stdout", Max: task.outputLimit(i, 0) + 1},
 				{Name: "stderr", Max: 10240},
 			},
 			CopyIn: map[string]CmdFile{
@@ -238,27 +258,32 @@ func (c *GoJudgeClient) runInterpretedLanguage(code, language string, inputs []s
 			MemoryLimit: memoryLimit,
 			ProcLimit:   50,
 		}
+		withFileIO(&runCmd, &task, input, task.outputLimit(i, 0))
 		runCmds = append(runCmds, runCmd)
 	}
 
 	runReqBody := map[string]interface{}{
 		"cmd": runCmds,
 	}
-	runResps, err := c.doRequest(runReqBody)
+	runResps, err := c.doRequest(task.context(), runReqBody)
 	if err != nil {
 		return nil, err
 	}
 
 	results := make([]models.TestCaseResult, len(inputs))
 	for i, resp := range runResps {
-		results[i] = parseResult(resp, inputs[i])
+		results[i] = parseResult(resp, inputs[i], task.outputLimit(i, 0), task.OutputFile)
+		task.caseDone(i, results[i])
 	}
 	return results, nil
 }
 
 // runJava 处理 Java (需要编译)
-func (c *GoJudgeClient) runJava(code string, inputs []string, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+func (c *GoJudgeClient) runJava(task JudgeTask, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+	code, inputs := task.Code, task.Inputs
+
 	// 1. Compile Main.java -> Main.class
+	task.stage(StageCompiling)
 	defaultEnv := "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
 	compileCmd := CmdRequest{
 		Args: []string{"javac", "Main.java"},
@@ -279,30 +304,32 @@ func (c *GoJudgeClient) runJava(code string, inputs []string, cpuLimit, clockLim
 	}
 
 	compileReqBody := map[string]interface{}{"cmd": []CmdRequest{compileCmd}}
-	compileResps, err := c.doRequest(compileReqBody)
+	compileResps, err := c.doRequest(task.context(), compileReqBody)
 	if err != nil {
 		return nil, err
 	}
 
 	if compileResps[0].Status != "Accepted" {
-		return getAllErrorResult(inputs, "Compile Error"), nil
+		return compileErrorResults(inputs, compileResps[0]), nil
 	}
 
 	classFileId := compileResps[0].FileIds["Main.class"]
 	if classFileId == "" {
 		return nil, fmt.Errorf("java compile success but no class fileId")
 	}
+	defer c.deleteFile(classFileId)
 
 	// 2. Run java
+	task.stage(StageRunning)
 	var runCmds []CmdRequest
-	for _, input := range inputs {
+	for i, input := range inputs {
 		inputContent := input
 		runCmd := CmdRequest{
-			Args: []string{"java", "Main"}, // 假设 CLASSPATH 默认包含 .
+			Args: append([]string{"java", "Main"}, task.args(i)...), // 假设 CLASSPATH 默认包含 .
 			Env:  []string{defaultEnv},
 			Files: []*CmdFile{
 				{Content: &inputContent},
-				{Name: "stdout", Max: 10240},
+				{Name: "stdout", Max: task.outputLimit(i, 0) + 1},
 				{Name: "stderr", Max: 10240},
 			},
 			CopyIn: map[string]CmdFile{
@@ -313,29 +340,124 @@ func (c *GoJudgeClient) runJava(code string, inputs []string, cpuLimit, clockLim
 			MemoryLimit: memoryLimit,
 			ProcLimit:   50,
 		}
+		withFileIO(&runCmd, &task, input, task.outputLimit(i, 0))
 		runCmds = append(runCmds, runCmd)
 	}
 
 	runReqBody := map[string]interface{}{"cmd": runCmds}
-	runResps, err := c.doRequest(runReqBody)
+	runResps, err := c.doRequest(task.context(), runReqBody)
 	if err != nil {
 		return nil, err
 	}
 
 	results := make([]models.TestCaseResult, len(inputs))
 	for i, resp := range runResps {
-		results[i] = parseResult(resp, inputs[i])
+		results[i] = parseResult(resp, inputs[i], task.outputLimit(i, 0), task.OutputFile)
+		task.caseDone(i, results[i])
 	}
 	return results, nil
 }
 
-func (c *GoJudgeClient) doRequest(body interface{}) ([]CmdResponse, error) {
+// runProject 处理多文件提交：在沙箱中执行构建命令，缓存构建产物后逐个运行测试用例
+func (c *GoJudgeClient) runProject(task JudgeTask, cpuLimit, clockLimit, memoryLimit uint64) ([]models.TestCaseResult, error) {
+	inputs := task.Inputs
+	artifact, runArgs := projectRunArgs(task.Language)
+	if runArgs == nil {
+		return nil, userError("unsupported language for multi-file submission: %s", task.Language)
+	}
+	defaultEnv := "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+	sources := make(map[string]CmdFile, len(task.Files))
+	for _, f := range task.Files {
+		content := f.Content
+		sources[f.Path] = CmdFile{Content: &content}
+	}
+
+	// 1. 构建（python 等无构建命令的语言直接运行源码）
+	runFiles := sources
+	if artifact != "" {
+		task.stage(StageCompiling)
+		buildCmd := CmdRequest{
+			Args: []string{"/bin/sh", "-c", task.BuildCommand},
+			Env:  []string{"GOCACHE=/tmp", "GOMODCACHE=/tmp", "HOME=/tmp", defaultEnv},
+			Files: []*CmdFile{
+				{Content: new(string)},
+				{Name: "stdout", Max: 10240},
+				{Name: "stderr", Max: 10240},
+			},
+			CopyIn:        sources,
+			CopyOutCached: []string{artifact},
+			CPULimit:      30 * 1000 * 1000 * 1000, // 项目构建给 30s
+			ClockLimit:    30 * 1000 * 1000 * 1000,
+			MemoryLimit:   1024 * 1024 * 1024,
+			ProcLimit:     100,
+		}
+		buildResps, err := c.doRequest(task.context(), map[string]interface{}{"cmd": []CmdRequest{buildCmd}})
+		if err != nil {
+			return nil, fmt.Errorf("build request failed: %w", err)
+		}
+		if buildResps[0].Status == "Internal Error" {
+			return nil, fmt.Errorf("build internal error: %s", buildResps[0].Error)
+		}
+		if buildResps[0].Status != "Accepted" {
+			return compileErrorResults(inputs, buildResps[0]), nil
+		}
+		fileID := buildResps[0].FileIds[artifact]
+		if fileID == "" {
+			return nil, fmt.Errorf("build success but no %s fileId returned", artifact)
+		}
+		defer c.deleteFile(fileID)
+		runFiles = map[string]CmdFile{artifact: {FileID: &fileID}}
+	}
+
+	// 2. 运行，copyIn 每个命令单独一份（withFileIO 会写入输入文件）
+	task.stage(StageRunning)
+	var runCmds []CmdRequest
+	for i, input := range inputs {
+		inputContent := input
+		copyIn := make(map[string]CmdFile, len(runFiles)+1)
+		for name, f := range runFiles {
+			copyIn[name] = f
+		}
+		runCmd := CmdRequest{
+			Args: append(append([]string{}, runArgs...), task.args(i)...),
+			Env:  []string{defaultEnv, "PYTHONIOENCODING=utf-8"},
+			Files: []*CmdFile{
+				{Content: &inputContent},
+				{Name: "stdout", Max: task.outputLimit(i, 0) + 1},
+				{Name: "stderr", Max: 10240},
+			},
+			CopyIn:      copyIn,
+			CPULimit:    cpuLimit,
+			ClockLimit:  clockLimit,
+			MemoryLimit: memoryLimit,
+			ProcLimit:   50,
+		}
+		withFileIO(&runCmd, &task, input, task.outputLimit(i, 0))
+		runCmds = append(runCmds, runCmd)
+	}
+
+	runResps, err := c.doRequest(task.context(), map[string]interface{}{"cmd": runCmds})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.TestCaseResult, len(inputs))
+	for i, resp := range runResps {
+		results[i] = parseResult(resp, inputs[i], task.outputLimit(i, 0), task.OutputFile)
+		task.caseDone(i, results[i])
+	}
+	return results, nil
+}
+
+// doRequest 发送 /run 请求；ctx 取消时连接断开，go-judge 随之终止沙箱中的进程
+func (c *GoJudgeClient) doRequest(ctx context.Context, body interface{}) ([]CmdResponse, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.APIURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.APIURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, err
 	}
@@ -361,16 +483,46 @@ func (c *GoJudgeClient) doRequest(body interface{}) ([]CmdResponse, error) {
 	return results, nil
 }
 
-func parseResult(resp CmdResponse, input string) models.TestCaseResult {
+// deleteFile 删除 go-judge 中缓存的编译产物（DELETE /file/:id），评测结束或被取消时调用
+func (c *GoJudgeClient) deleteFile(fileID string) {
+	url := strings.TrimSuffix(c.APIURL, "/run") + "/file/" + fileID
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		log.Printf("删除 go-judge 缓存文件失败(id=%s): %v", fileID, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func parseResult(resp CmdResponse, input string, outputLimit int64, outputFile string) models.TestCaseResult {
 	r := models.TestCaseResult{
 		Input:       input,
 		Runtime:     int64(resp.Time / 1_000_000), // ns -> ms
 		MemoryUsage: int64(resp.Memory / 1024),    // byte -> KB
+		ExitCode:    resp.ExitStatus,
 	}
 
-	// 获取 stdout
+	// 获取 stdout / stderr，只有 stdout（文件 IO 时为输出文件）参与比对
 	if resp.Files != nil {
 		r.ActualOutput = resp.Files["stdout"]
+		if outputFile != "" {
+			r.ActualOutput = resp.Files[outputFile]
+		}
+		r.Stderr = truncateOutput(resp.Files["stderr"], maxStderrSize)
+	}
+
+	// 输出超出上限（go-judge 会截断 stdout 到 Max；输出文件超过 copyOutMax 时返回 File Error）
+	if resp.Status == "Output Limit Exceeded" || int64(len(r.ActualOutput)) > outputLimit ||
+		(outputFile != "" && resp.Status == "File Error") {
+		r.ActualOutput = "Output Limit Exceeded"
+		r.Verdict = models.VerdictOutputLimitExceeded
+		return r
 	}
 
 	// Status Mappings
@@ -378,19 +530,58 @@ func parseResult(resp CmdResponse, input string) models.TestCaseResult {
 	case "Accepted":
 	case "Time Limit Exceeded":
 		r.ActualOutput = "Time Limit Exceeded"
+		r.Verdict = models.VerdictTimeLimitExceeded
 	case "Memory Limit Exceeded":
 		r.ActualOutput = "Memory Limit Exceeded"
+		r.Verdict = models.VerdictMemoryLimitExceeded
 	case "Signalled":
-		r.ActualOutput = fmt.Sprintf("Runtime Error (Signal %s)", resp.Error)
+		// go-judge 在被信号终止时 exitStatus 为信号编号，这里统一为 shell 约定的 128+n
+		r.Signal = signalName(resp.ExitStatus)
+		r.ExitCode = 128 + resp.ExitStatus
+		r.ActualOutput = fmt.Sprintf("Runtime Error (Signal %s)", r.Signal)
+		r.Verdict = models.VerdictRuntimeError
 	case "Non Zero Exit Status":
 		r.ActualOutput = "Runtime Error (Non Zero Exit)"
+		r.Verdict = models.VerdictRuntimeError
 	default:
 		r.ActualOutput = fmt.Sprintf("Error: %s", resp.Status)
+		r.Verdict = models.VerdictRuntimeError
 	}
 
 	return r
 }
 
+// withFileIO 文件 IO 题目：输入作为文件放入沙箱，输出文件通过 copyOut 取回（文件可能不存在，故标记为可选）
+func withFileIO(cmd *CmdRequest, task *JudgeTask, input string, outputLimit int64) {
+	if task.InputFile != "" {
+		content := input
+		cmd.Files[0] = &CmdFile{Content: new(string)}
+		cmd.CopyIn[task.InputFile] = CmdFile{Content: &content}
+	}
+	if task.OutputFile != "" {
+		cmd.CopyOut = append(cmd.CopyOut, task.OutputFile+"?")
+		cmd.CopyOutMax = uint64(outputLimit) + 1
+	}
+}
+
+// compileErrorResults 生成编译错误结果，编译器输出放在 stderr 中
+func compileErrorResults(inputs []string, resp CmdResponse) []models.TestCaseResult {
+	msg := resp.Files["stderr"]
+	if msg == "" {
+		msg = resp.Files["stdout"]
+	}
+	if msg == "" {
+		msg = resp.Error
+	}
+	res := getAllErrorResult(inputs, "Compile Error")
+	for i := range res {
+		res[i].Verdict = models.VerdictCompileError
+		res[i].Stderr = truncateOutput(msg, maxStderrSize)
+		res[i].ExitCode = resp.ExitStatus
+	}
+	return res
+}
+
 func getAllErrorResult(inputs []string, msg string) []models.TestCaseResult {
 	res := make([]models.TestCaseResult, len(inputs))
 	for i := range inputs {
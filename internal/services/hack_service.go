@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxHackInputSize hack 输入的最大字节数
+const MaxHackInputSize = 1 << 20
+
+// maxHackOutputSize 保存目标提交输出的最大字节数
+const maxHackOutputSize = 4096
+
+// HackService 执行 hack：校验输入、用主参考解得到答案，再评测目标提交
+type HackService struct {
+	DB     *gorm.DB
+	Setter *ProblemSetterService
+}
+
+// NewHackService 创建 hack 服务
+func NewHackService(db *gorm.DB, setter *ProblemSetterService) *HackService {
+	return &HackService{DB: db, Setter: setter}
+}
+
+// Start 保存 hack 并在后台执行
+func (hs *HackService) Start(hack *models.Hack) error {
+	hack.Status = models.HackPending
+	if err := hs.DB.Create(hack).Error; err != nil {
+		return fmt.Errorf("创建 hack 失败: %w", err)
+	}
+	go hs.run(*hack)
+	return nil
+}
+
+// run 执行 hack 并保存结果
+func (hs *HackService) run(hack models.Hack) {
+	hs.DB.Model(&hack).Update("status", models.HackRunning)
+	if err := hs.execute(&hack); err != nil {
+		log.Printf("hack 执行失败 id=%d err=%v", hack.ID, err)
+		hack.Status = models.HackError
+		hack.Message = err.Error()
+	}
+	if err := hs.DB.Save(&hack).Error; err != nil {
+		log.Printf("保存 hack 结果失败 id=%d err=%v", hack.ID, err)
+	}
+}
+
+// execute 依次执行输入校验、参考解、目标提交，结论写入 hack
+func (hs *HackService) execute(hack *models.Hack) error {
+	var question models.Question
+	if err := hs.DB.Where("id = ?", hack.QuestionID).First(&question).Error; err != nil {
+		return fmt.Errorf("查询题目失败: %w", err)
+	}
+	var target models.Submission
+	if err := hs.DB.Where("id = ?", hack.TargetSubmissionID).First(&target).Error; err != nil {
+		return fmt.Errorf("查询目标提交失败: %w", err)
+	}
+
+	// 1. 输入校验
+	validator, err := hs.Setter.InputValidator(question.Id)
+	if err != nil {
+		return err
+	}
+	if validator != nil {
+		checks, err := hs.Setter.CheckInputs(validator, []string{hack.Input})
+		if err != nil {
+			return err
+		}
+		if !checks[0].Valid {
+			hack.Status = models.HackInvalid
+			hack.Message = checks[0].Message
+			return nil
+		}
+	}
+
+	// 2. 主参考解生成答案
+	var main models.ReferenceSolution
+	if err := hs.DB.Where("question_id = ? AND is_main = ?", question.Id, true).First(&main).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("题目没有设置主参考解")
+		}
+		return fmt.Errorf("查询主参考解失败: %w", err)
+	}
+	expected, err := hs.Setter.RunReference(&question, &main, hack.Input)
+	if err != nil {
+		return err
+	}
+	hack.ExpectedOutput = expected
+
+	// 3. 评测目标提交
+	testCase := models.TestCase{QuestionID: question.Id, Input: hack.Input, ExpectedOutput: expected}
+	results, err := hs.Setter.Judge.JudgeTestCases(&question, target.Code, target.Language, []models.TestCase{testCase})
+	if err != nil {
+		return fmt.Errorf("评测目标提交失败: %w", err)
+	}
+	if len(results) != 1 {
+		return fmt.Errorf("目标提交评测结果数量不匹配: got=%d", len(results))
+	}
+	r := results[0]
+	hack.Verdict = models.OverallVerdict(results)
+	hack.ActualOutput = truncateOutput(r.ActualOutput, maxHackOutputSize)
+	if hack.Verdict == models.VerdictAccepted {
+		hack.Status = models.HackFailed
+		hack.Message = "目标提交通过了该输入"
+	} else {
+		hack.Status = models.HackSuccess
+		hack.Message = fmt.Sprintf("目标提交在该输入上得到 %s", hack.Verdict)
+	}
+	return nil
+}
+
+// AddToTests 将成功的 hack 输入加入题目的测试数据，完整评测时与其他测试用例一起运行
+func (hs *HackService) AddToTests(hack *models.Hack) (*models.TestCase, error) {
+	if hack.Status != models.HackSuccess {
+		return nil, fmt.Errorf("只有成功的 hack 可以加入测试数据")
+	}
+	if hack.TestCaseID != nil {
+		return nil, fmt.Errorf("该 hack 已加入测试数据")
+	}
+	// 没有测试用例记录时评测读取 OSS 中的测试数据，新增一条记录会使其余测试数据失效
+	var count int64
+	if err := hs.DB.Model(&models.TestCase{}).Where("question_id = ?", hack.QuestionID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("该题测试数据仅保存在 OSS，请先提交为测试用例")
+	}
+
+	testCase := models.TestCase{
+		QuestionID:     hack.QuestionID,
+		Input:          hack.Input,
+		ExpectedOutput: hack.ExpectedOutput,
+	}
+	err := hs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&testCase).Error; err != nil {
+			return err
+		}
+		hack.TestCaseID = &testCase.ID
+		return tx.Model(hack).Update("test_case_id", testCase.ID).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建测试用例失败: %w", err)
+	}
+	if err := models.TestDataChanged(hack.QuestionID); err != nil {
+		return nil, fmt.Errorf("重置校验状态失败: %w", err)
+	}
+	return &testCase, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// InputCheck 单个测试输入的校验结果
+type InputCheck struct {
+	TestCaseID uint   `json:"test_case_id,omitempty"`
+	Index      int    `json:"index"` // 在本次校验中的序号（从 1 开始）
+	Valid      bool   `json:"valid"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Apply 将校验结果写入测试用例（不保存）
+func (ic InputCheck) Apply(tc *models.TestCase) {
+	tc.ValidationStatus = models.InputInvalid
+	if ic.Valid {
+		tc.ValidationStatus = models.InputValid
+	}
+	tc.ValidationMessage = ic.Message
+}
+
+// InputValidationSummary 校验题目全部测试输入的结果
+type InputValidationSummary struct {
+	HasValidator bool         `json:"has_validator"`
+	Total        int          `json:"total"`
+	Invalid      int          `json:"invalid"`
+	Checks       []InputCheck `json:"checks"`
+}
+
+// InputValidator 查询题目的输入校验器，没有设置时返回 nil
+func (ps *ProblemSetterService) InputValidator(questionID int) (*models.InputValidator, error) {
+	var v models.InputValidator
+	err := ps.DB.Where("question_id = ?", questionID).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询输入校验器失败: %w", err)
+	}
+	return &v, nil
+}
+
+// CheckInputs 在沙箱中用校验器逐个检查输入；退出码非 0 视为非法，stderr 作为原因
+// 校验器编译失败或沙箱异常时返回 error
+func (ps *ProblemSetterService) CheckInputs(v *models.InputValidator, inputs []string) ([]InputCheck, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	language := v.Language
+	if language == "" {
+		language = ps.Judge.DetectLanguage(v.Code)
+	}
+	results, err := ps.Judge.Run(JudgeTask{Code: v.Code, Language: language, Inputs: inputs})
+	if err != nil {
+		return nil, fmt.Errorf("运行输入校验器失败: %w", err)
+	}
+	if len(results) != len(inputs) {
+		return nil, fmt.Errorf("输入校验器运行结果数量不匹配: got=%d want=%d", len(results), len(inputs))
+	}
+
+	checks := make([]InputCheck, len(results))
+	for i, r := range results {
+		check := InputCheck{Index: i + 1}
+		switch r.Verdict {
+		case "", models.VerdictAccepted:
+			check.Valid = true
+		case models.VerdictCompileError:
+			return nil, fmt.Errorf("输入校验器编译失败: %s", r.Stderr)
+		case models.VerdictRuntimeError:
+			check.Message = strings.TrimSpace(r.Stderr)
+			if check.Message == "" {
+				check.Message = r.ActualOutput
+			}
+		default:
+			check.Message = "校验器运行异常: " + r.Verdict
+		}
+		checks[i] = check
+	}
+	return checks, nil
+}
+
+// ValidateTestCases 校验题目的全部测试输入并保存每个测试用例的校验状态
+func (ps *ProblemSetterService) ValidateTestCases(ctx context.Context, questionID int) (*InputValidationSummary, error) {
+	v, err := ps.InputValidator(questionID)
+	if err != nil {
+		return nil, err
+	}
+	var testCases []models.TestCase
+	if err := ps.DB.Where("question_id = ?", questionID).Order("id ASC").Find(&testCases).Error; err != nil {
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+	summary := &InputValidationSummary{HasValidator: v != nil, Total: len(testCases)}
+	if v == nil {
+		return summary, nil
+	}
+
+	inputs := make([]string, len(testCases))
+	for i, tc := range testCases {
+		inputs[i], _, err = LoadTestCaseIO(ctx, ps.OSSClient, ps.OSSBucket, tc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	checks, err := ps.CheckInputs(v, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checks {
+		checks[i].TestCaseID = testCases[i].ID
+		checks[i].Apply(&testCases[i])
+		if err := ps.DB.Model(&testCases[i]).Updates(map[string]interface{}{
+			"validation_status":  testCases[i].ValidationStatus,
+			"validation_message": testCases[i].ValidationMessage,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("保存校验状态失败: %w", err)
+		}
+		if !checks[i].Valid {
+			summary.Invalid++
+		}
+	}
+	summary.Checks = checks
+	return summary, nil
+}
+
+// ClearInputValidation 校验器变更后清除题目下所有测试用例的校验状态
+func ClearInputValidation(db *gorm.DB, questionID int) error {
+	return db.Model(&models.TestCase{}).Where("question_id = ?", questionID).
+		Updates(map[string]interface{}{"validation_status": "", "validation_message": ""}).Error
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 系统错误类别
+const (
+	SysErrNetwork  = "network"   // 远程评测机不可达
+	SysErrSandbox  = "sandbox"   // 沙箱（docker / go-judge）执行失败
+	SysErrStorage  = "storage"   // OSS 读取失败
+	SysErrTestData = "test_data" // 测试数据缺失或题目配置有误
+	SysErrDatabase = "database"  // 数据库读写失败
+	SysErrUnknown  = "unknown"   // 未归类的失败
+)
+
+// SystemError 评测基础设施故障，与提交本身无关，可自动重试
+type SystemError struct {
+	Kind string
+	Err  error
+}
+
+func (e *SystemError) Error() string { return e.Err.Error() }
+
+func (e *SystemError) Unwrap() error { return e.Err }
+
+// systemError 以指定类别包装错误
+func systemError(kind string, format string, args ...interface{}) error {
+	return &SystemError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// UserError 由提交本身导致的失败（如语言不受支持），重试无意义
+type UserError struct {
+	Err error
+}
+
+func (e *UserError) Error() string { return e.Err.Error() }
+
+func (e *UserError) Unwrap() error { return e.Err }
+
+// userError 包装为提交本身的错误
+func userError(format string, args ...interface{}) error {
+	return &UserError{Err: fmt.Errorf(format, args...)}
+}
+
+// ClassifyJudgeError 判断评测失败是否为系统错误及其类别
+// 未归类的错误按系统错误处理：宁可重试，也不把评测机的问题算到学生头上
+func ClassifyJudgeError(err error) (kind string, system bool) {
+	var ue *UserError
+	if errors.As(err, &ue) {
+		return "", false
+	}
+	var se *SystemError
+	if errors.As(err, &se) {
+		return se.Kind, true
+	}
+	return SysErrUnknown, true
+}
+
+// classifyRunError 评测后端返回的未归类错误视为沙箱故障
+func classifyRunError(err error) error {
+	var ue *UserError
+	var se *SystemError
+	if errors.As(err, &ue) || errors.As(err, &se) {
+		return err
+	}
+	return &SystemError{Kind: SysErrSandbox, Err: err}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 评测事件类型
+const (
+	EventQueued    = "queued"    // 排队中（携带队列位置）
+	EventStage     = "stage"     // 进入编译/运行阶段
+	EventCase      = "case"      // 单个测试用例运行结束
+	EventFinished  = "finished"  // 评测结束（completed / error / cancelled，或系统错误且不再重试）
+	EventRetry     = "retry"     // 系统错误，等待自动重试
+	StageCompiling = "compiling" // 编译阶段
+	StageRunning   = "running"   // 运行阶段
+)
+
+// EventClarification 比赛答疑有新提问、回复或公告，Status 为 asked / answered / broadcast
+const EventClarification = "clarification"
+
+// eventBufferSize 每个订阅者的事件缓冲，消费过慢时丢弃新事件而不是阻塞评测
+const eventBufferSize = 64
+
+// JudgeEvent 评测进度事件
+type JudgeEvent struct {
+	Type         string    `json:"type"`
+	SubmissionID string    `json:"submission_id"`
+	UserID       string    `json:"user_id"`
+	ContestID    uint      `json:"contest_id,omitempty"` // 比赛提交所属的比赛（排队、结束与重试事件携带）
+	Position     int       `json:"position,omitempty"`   // 队列位置，1 表示下一个被评测
+	Stage        string    `json:"stage,omitempty"`
+	Case         int       `json:"case,omitempty"` // 从 1 开始
+	Total        int       `json:"total,omitempty"`
+	Verdict      string    `json:"verdict,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Time         time.Time `json:"time"`
+
+	// 比赛答疑事件的答疑 ID，只作通知，内容通过答疑列表获取
+	ClarificationID uint `json:"clarification_id,omitempty"`
+}
+
+// EventSubscription 事件订阅
+type EventSubscription struct {
+	C      chan JudgeEvent
+	filter func(JudgeEvent) bool
+
+	dropped atomic.Bool // 缓冲已满时丢弃过事件
+}
+
+// Dropped 返回自上次调用以来是否因缓冲已满丢弃过事件，并清除该标记
+func (sub *EventSubscription) Dropped() bool {
+	return sub.dropped.Swap(false)
+}
+
+// EventHub 进程内的评测事件广播中心
+type EventHub struct {
+	mu   sync.RWMutex
+	subs map[*EventSubscription]struct{}
+}
+
+// NewEventHub 创建事件中心
+func NewEventHub() *EventHub {
+	return &EventHub{subs: make(map[*EventSubscription]struct{})}
+}
+
+// Subscribe 订阅满足 filter 的事件，filter 为 nil 时接收全部事件
+func (h *EventHub) Subscribe(filter func(JudgeEvent) bool) *EventSubscription {
+	return h.SubscribeBuffered(filter, eventBufferSize)
+}
+
+// SubscribeBuffered 以指定缓冲大小订阅，供不能容忍丢事件的进程内消费者使用
+func (h *EventHub) SubscribeBuffered(filter func(JudgeEvent) bool, size int) *EventSubscription {
+	sub := &EventSubscription{C: make(chan JudgeEvent, size), filter: filter}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe 取消订阅并关闭通道
+func (h *EventHub) Unsubscribe(sub *EventSubscription) {
+	h.mu.Lock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+	h.mu.Unlock()
+}
+
+// Publish 向所有匹配的订阅者推送事件，不会阻塞
+func (h *EventHub) Publish(ev JudgeEvent) {
+	if h == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(ev) {
+			continue
+		}
+		select {
+		case sub.C <- ev:
+		default:
+			sub.dropped.Store(true)
+		}
+	}
+}
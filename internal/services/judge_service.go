@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -16,6 +17,8 @@ import (
 
 	"dachuang/internal/config"
 	"dachuang/internal/graph"
+	"dachuang/internal/harness"
+	"dachuang/internal/metrics"
 	"dachuang/internal/models"
 	"dachuang/internal/oss"
 
@@ -31,6 +34,7 @@ type JudgeService struct {
 	Config            *config.JudgeConfig // 评测配置
 	OSSClient         *oss.OSS
 	OSSBucket         string
+	Events            *EventHub // 评测进度事件
 
 	// 图相关的服务和评估服务
 	GraphService      *graph.QuestionGraphService
@@ -61,6 +65,7 @@ func NewJudgeService(cfg *config.JudgeConfig, db *gorm.DB, ossClient *oss.OSS, o
 		GoJudgeClient:     goJudgeClient,
 		OSSClient:         ossClient,
 		OSSBucket:         ossBucket,
+		Events:            NewEventHub(),
 		GraphService:      graphService,
 		AssessmentService: assessmentService,
 		HTTPClient: &http.Client{
@@ -69,15 +74,21 @@ func NewJudgeService(cfg *config.JudgeConfig, db *gorm.DB, ossClient *oss.OSS, o
 	}
 }
 
-// JudgeCode 执行代码评测
-func (js *JudgeService) JudgeCode(submission *models.Submission) error {
+// JudgeCode 执行代码评测，ctx 取消时中断评测并返回 ctx.Err()
+// tl 记录各阶段耗时，返回前写入 submission.Timeline，由调用方随最终状态一起保存
+func (js *JudgeService) JudgeCode(ctx context.Context, submission *models.Submission, tl *Timeline) error {
 	// 1. 验证提交状态
 	if submission.Status == "completed" {
 		return fmt.Errorf("提交已完成评测，无需重复评测")
 	}
+	defer func() {
+		submission.Timeline = tl.JSON()
+	}()
 
-	// 2. 获取测试用例
-	testCases, err := js.getTestCases(submission.QuestionID)
+	// 2. 获取测试用例（样例评测模式只取样例）
+	tl.Begin(TimelineFetch)
+	testCases, err := js.getTestCases(submission.QuestionID, submission.JudgeMode)
+	tl.End(TimelineFetch)
 	if err != nil {
 		return fmt.Errorf("获取测试用例失败: %w", err)
 	}
@@ -85,15 +96,60 @@ func (js *JudgeService) JudgeCode(submission *models.Submission) error {
 	// 3. 准备评测
 	submission.Status = "processing"
 	if err = js.DB.Save(submission).Error; err != nil {
-		return fmt.Errorf("更新提交状态失败: %w", err)
+		return systemError(SysErrDatabase, "更新提交状态失败: %w", err)
 	}
 
 	log.Print("debug")
 
-	// 4. 执行评测
-	results, err := js.executeJudgement(submission.Code, testCases)
-	if err != nil {
-		return fmt.Errorf("执行评测失败: %w", err)
+	var question models.Question
+	if err := js.DB.Where("id = ?", submission.QuestionID).First(&question).Error; err != nil {
+		return systemError(SysErrDatabase, "查询题目失败: %w", err)
+	}
+
+	// 多文件提交从 OSS 读取全部源文件
+	var files []SourceFile
+	if submission.FileCount > 0 {
+		tl.Begin(TimelineFetch)
+		files, err = LoadSubmissionFiles(ctx, js.OSSClient, js.OSSBucket, submission.SourceKey)
+		tl.End(TimelineFetch)
+		if err != nil {
+			return systemError(SysErrStorage, "%w", err)
+		}
+	}
+
+	if submission.Language == "" && files != nil {
+		submission.Language = DetectProjectLanguage(files)
+	} else if submission.Language == "" {
+		submission.Language = js.detectLanguage(submission.Code)
+	}
+
+	// 4. 相同代码与测试数据已评测过时直接复用结果，否则执行评测
+	// 无法取得 OSS 测试数据摘要时不查找也不提供缓存
+	submission.ResultHash = ""
+	if js.Config.ResultCache {
+		if digest, err := js.testDataDigest(ctx, &question, testCases); err != nil {
+			log.Printf("获取测试数据摘要失败，跳过结果复用: %v", err)
+		} else {
+			submission.ResultHash = js.ResultHash(&question, submission, files, digest)
+		}
+	}
+	submission.CachedFrom = ""
+	var results []models.TestCaseResult
+	cacheStart := time.Now()
+	if cached, ok := js.findCachedResult(submission); ok && json.Unmarshal([]byte(cached.Results), &results) == nil && len(results) == len(testCases) {
+		applyCachedResult(submission, cached)
+		metrics.ResultCacheHits.Inc()
+		tl.Add(TimelineCache, cacheStart, time.Now())
+	} else {
+		notify := func(ev JudgeEvent) {
+			ev.SubmissionID = submission.ID
+			ev.UserID = submission.UserID
+			js.Events.Publish(ev)
+		}
+		results, err = js.executeJudgement(ctx, &question, submission.Code, submission.Language, files, testCases, notify, tl)
+		if err != nil {
+			return fmt.Errorf("执行评测失败: %w", err)
+		}
 	}
 
 	var maxRuntime int64
@@ -107,27 +163,53 @@ func (js *JudgeService) JudgeCode(submission *models.Submission) error {
 		}
 	}
 
-	if submission.Language == "" {
-		submission.Language = js.detectLanguage(submission.Code)
-	}
 	if submission.CodeLength == 0 {
 		submission.CodeLength = len(submission.Code)
 	}
 	submission.RuntimeMs = maxRuntime
 	submission.MemoryKB = maxMem
 
+	// 按当前的子任务划分计算得分（复用的结果也按当前划分重新计算），样例评测不计分
+	for i := range results {
+		results[i].Subtask = testCases[i].Subtask
+	}
+	submission.Score = 0
+	submission.SubtaskScores = ""
+	if submission.JudgeMode != models.JudgeModeSample {
+		score, subtaskScores := ScoreResults(question.SubtaskList(), results)
+		scoresJSON, err := json.Marshal(subtaskScores)
+		if err != nil {
+			return fmt.Errorf("序列化子任务得分失败: %w", err)
+		}
+		submission.Score = score
+		submission.SubtaskScores = string(scoresJSON)
+	}
+
 	// 5. 保存结果
 	resultsJSON, err := json.Marshal(results)
 	if err != nil {
 		return fmt.Errorf("序列化测试结果失败: %w", err)
 	}
 	submission.Results = string(resultsJSON)
+	submission.Verdict = models.OverallVerdict(results)
+	metrics.Verdicts.WithLabelValues(submission.Language, submission.Verdict).Inc()
+	if submission.Verdict == models.VerdictCompileError {
+		metrics.CompileFailures.WithLabelValues(submission.Language).Inc()
+	}
 	submission.Status = "completed"
-	if err := js.DB.Save(submission).Error; err != nil {
-		return fmt.Errorf("保存评测结果失败: %w", err)
+	tl.Begin(TimelineSave)
+	err = js.DB.Save(submission).Error
+	tl.End(TimelineSave)
+	if err != nil {
+		return systemError(SysErrDatabase, "保存评测结果失败: %w", err)
 	}
 
-	// 6. 如果评测通过，触发能力评估更新
+	// 6. 样例评测不计入掌握度
+	if submission.JudgeMode == models.JudgeModeSample {
+		return nil
+	}
+
+	// 7. 如果评测通过，触发能力评估更新
 	isAc := true
 	for _, r := range results {
 		if !r.IsCorrect {
@@ -145,35 +227,236 @@ func (js *JudgeService) JudgeCode(submission *models.Submission) error {
 	return nil
 }
 
-// executeJudgement 执行实际评测逻辑
-func (js *JudgeService) executeJudgement(code string, testCases []models.TestCase) ([]models.TestCaseResult, error) {
-	// 根据配置选择评测方式
+// JudgeTestCases 用指定测试用例评测一段代码（不落库），供参考解校验等场景使用
+func (js *JudgeService) JudgeTestCases(question *models.Question, code, language string, testCases []models.TestCase) ([]models.TestCaseResult, error) {
+	return js.executeJudgement(context.Background(), question, code, language, nil, testCases, nil, nil)
+}
+
+// ApplyHarness 函数签名题：把用户代码与驱动程序合并为多文件任务，标准题不做处理
+func (js *JudgeService) ApplyHarness(question *models.Question, task *JudgeTask) error {
+	if !question.IsFunction() {
+		return nil
+	}
+	if task.isProject() {
+		return userError("函数签名题不支持多文件提交")
+	}
+	sig, err := harness.ParseSignature(question.Signature)
+	if err != nil {
+		return systemError(SysErrTestData, "%w", err)
+	}
+	program, err := harness.Build(sig, task.Language, task.Code)
+	if err != nil {
+		return userError("%w", err)
+	}
+	task.Files = make([]SourceFile, len(program.Files))
+	for i, f := range program.Files {
+		task.Files[i] = SourceFile{Path: f.Path, Content: f.Content}
+	}
+	task.BuildCommand = program.BuildCommand
+	return nil
+}
+
+// executeJudgement 执行实际评测逻辑，notify 非空时推送阶段与逐个用例的进度，tl 非空时记录各阶段耗时
+// language 为空时根据代码自动识别；files 非空时为多文件提交，忽略 code
+func (js *JudgeService) executeJudgement(ctx context.Context, question *models.Question, code, language string, files []SourceFile, testCases []models.TestCase, notify func(JudgeEvent), tl *Timeline) ([]models.TestCaseResult, error) {
+	// 1. 准备输入数据与每个用例的输出上限
+	tl.Begin(TimelineFetch)
+	inputs := make([]string, 0, len(testCases))
+	expectedList := make([]string, 0, len(testCases))
+	outputLimits := make([]int64, 0, len(testCases))
+	for _, tc := range testCases {
+		input, expected, err := js.loadTestCaseIO(ctx, tc)
+		if err != nil {
+			return nil, systemError(SysErrStorage, "%w", err)
+		}
+		inputs = append(inputs, input)
+		expectedList = append(expectedList, expected)
+		outputLimits = append(outputLimits, js.outputLimitFor(question, len(expected)))
+	}
+	tl.End(TimelineFetch)
+
+	if language == "" && files != nil {
+		language = DetectProjectLanguage(files)
+	} else if language == "" {
+		language = js.detectLanguage(code)
+	}
+	task := JudgeTask{
+		Code:          code,
+		Language:      language,
+		Inputs:        inputs,
+		OutputLimits:  outputLimits,
+		InputFile:     question.InputFile,
+		OutputFile:    question.OutputFile,
+		TimeLimitMs:   int64(question.TimeLimit),
+		MemoryLimitMB: int64(question.MemoryLimit),
+		Ctx:           ctx,
+	}
+	if files != nil {
+		task.Files = files
+		task.BuildCommand = js.Config.MultiFile.Build[language]
+	}
+	if err := js.ApplyHarness(question, &task); err != nil {
+		return nil, err
+	}
+	if notify != nil || tl != nil {
+		total := len(inputs)
+		task.OnStage = func(stage string) {
+			switch stage {
+			case StageCompiling:
+				tl.Begin(TimelineCompile)
+			case StageRunning:
+				tl.End(TimelineCompile)
+				tl.Begin(TimelineRun)
+			}
+			if notify != nil {
+				notify(JudgeEvent{Type: EventStage, Stage: stage, Total: total})
+			}
+		}
+		task.OnCase = func(i int, r models.TestCaseResult) {
+			if i < 0 || i >= total {
+				return
+			}
+			tl.Case(i+1, r.Runtime)
+			if notify == nil {
+				return
+			}
+			r.ExpectedOutput = normalizeOutput(expectedList[i])
+			judgeOutput(&r)
+			notify(JudgeEvent{Type: EventCase, Case: i + 1, Total: total, Verdict: r.Verdict})
+		}
+	}
+
+	// 2. 交给评测后端执行；编译失败时不会进入运行阶段
+	results, err := js.Run(task)
+	tl.End(TimelineCompile)
+	tl.End(TimelineRun)
+	if err != nil {
+		return nil, classifyRunError(err)
+	}
+	if len(results) != len(inputs) {
+		return nil, systemError(SysErrSandbox, "评测结果数量不匹配: got=%d want=%d", len(results), len(inputs))
+	}
+
+	// 3. 比对结果
+	tl.Begin(TimelineCompare)
+	for i := range results {
+		results[i].Input = inputs[i]
+		results[i].IsHidden = testCases[i].IsHidden
+		results[i].ExpectedOutput = normalizeOutput(expectedList[i])
+		judgeOutput(&results[i])
+	}
+	tl.End(TimelineCompare)
+
+	return results, nil
+}
+
+// Run 根据配置选择评测后端执行任务，返回未经比对的原始运行结果
+func (js *JudgeService) Run(task JudgeTask) ([]models.TestCaseResult, error) {
+	var results []models.TestCaseResult
+	var err error
+	start := time.Now()
 	if js.Config.Mode == "local" && js.LocalJudgeService != nil {
 		// 本地评测
 		log.Printf("Local judge mode enabled")
 
-		return js.executeLocalJudgement(code, testCases)
+		results, err = js.executeLocalJudgement(task)
 	} else {
 		// 远程API评测
-		return js.executeRemoteJudgement(code, testCases)
+		results, err = js.executeRemoteJudgement(task)
+	}
+	if err != nil {
+		return nil, err
 	}
+	metrics.JudgeDuration.WithLabelValues(js.backendName(), task.Language).Observe(time.Since(start).Seconds())
+	return results, nil
+}
+
+// MaxOutputLimit 配置允许的最大输出上限（字节），用于生成标准输出等没有期望输出可参考的场景
+func (js *JudgeService) MaxOutputLimit() int64 {
+	if js.Config.OutputLimit.MaxKB > 0 {
+		return int64(js.Config.OutputLimit.MaxKB) * 1024
+	}
+	return defaultOutputLimit
+}
+
+// backendName 当前使用的评测后端，用于监控指标
+func (js *JudgeService) backendName() string {
+	if js.Config.Mode == "local" && js.LocalJudgeService != nil {
+		if strings.EqualFold(strings.TrimSpace(js.LocalJudgeService.Config.Executor), "docker") {
+			return "docker"
+		}
+		return "host"
+	}
+	return "go-judge"
+}
+
+// judgeOutput 比对标准输出并给出结论；执行器已判定的异常结论（TLE/RE/OLE 等）保持不变
+func judgeOutput(r *models.TestCaseResult) {
+	if r.Verdict != "" && r.Verdict != models.VerdictAccepted {
+		r.IsCorrect = false
+		return
+	}
+	r.ActualOutput = strings.TrimSpace(r.ActualOutput)
+	r.IsCorrect = normalizeOutput(r.ActualOutput) == r.ExpectedOutput
+	if r.IsCorrect {
+		r.Verdict = models.VerdictAccepted
+	} else {
+		r.Verdict = models.VerdictWrongAnswer
+	}
+}
+
+// outputLimitFor 计算单个测试用例的输出上限（字节）
+// 题目设置了 output_limit 时直接使用，否则按期望输出大小的倍数估算
+func (js *JudgeService) outputLimitFor(question *models.Question, expectedSize int) int64 {
+	if question != nil && question.OutputLimit > 0 {
+		return int64(question.OutputLimit) * 1024
+	}
+
+	cfg := js.Config.OutputLimit
+	factor := cfg.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+	limit := int64(float64(expectedSize) * factor)
+	if minLimit := int64(cfg.MinKB) * 1024; limit < minLimit {
+		limit = minLimit
+	}
+	if cfg.MaxKB > 0 {
+		if maxLimit := int64(cfg.MaxKB) * 1024; limit > maxLimit {
+			limit = maxLimit
+		}
+	}
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+	return limit
 }
 
 // loadTestCaseIO 加载测试用例的输入和输出
 func (js *JudgeService) loadTestCaseIO(ctx context.Context, tc models.TestCase) (string, string, error) {
+	input, expected, err := LoadTestCaseIO(ctx, js.OSSClient, js.OSSBucket, tc)
+	if err != nil {
+		return "", "", err
+	}
+	return input, strings.TrimSpace(expected), nil
+}
+
+// LoadTestCaseIO 读取测试用例的原始输入和输出（优先 OSS，其次数据库中的文本）
+// 题面样例展示与评测共用该方法，保证两者数据一致
+func LoadTestCaseIO(ctx context.Context, ossClient *oss.OSS, bucket string, tc models.TestCase) (string, string, error) {
 	input := tc.Input
 	expected := tc.ExpectedOutput
 
-	if js.OSSClient != nil && js.OSSBucket != "" {
+	if ossClient != nil && bucket != "" {
 		if tc.InputKey != "" {
-			b, err := js.OSSClient.GetObjectBytes(ctx, js.OSSBucket, tc.InputKey)
+			b, err := ossClient.GetObjectBytes(ctx, bucket, tc.InputKey)
 			if err != nil {
 				return "", "", fmt.Errorf("读取测试用例输入失败(key=%s): %w", tc.InputKey, err)
 			}
 			input = string(b)
 		}
 		if tc.OutputKey != "" {
-			b, err := js.OSSClient.GetObjectBytes(ctx, js.OSSBucket, tc.OutputKey)
+			b, err := ossClient.GetObjectBytes(ctx, bucket, tc.OutputKey)
 			if err != nil {
 				return "", "", fmt.Errorf("读取测试用例输出失败(key=%s): %w", tc.OutputKey, err)
 			}
@@ -181,50 +464,43 @@ func (js *JudgeService) loadTestCaseIO(ctx context.Context, tc models.TestCase)
 		}
 	}
 
-	return input, strings.TrimSpace(expected), nil
+	return input, expected, nil
 }
 
 // executeLocalJudgement 执行本地评测
-func (js *JudgeService) executeLocalJudgement(code string, testCases []models.TestCase) ([]models.TestCaseResult, error) {
-	// 检测编程语言（简单实现，可以根据代码内容或用户选择来确定）
-	language := js.detectLanguage(code)
-	log.Printf("Detected language: %s", language)
+func (js *JudgeService) executeLocalJudgement(task JudgeTask) ([]models.TestCaseResult, error) {
+	log.Printf("Detected language: %s", task.Language)
 
 	// 检查是否支持该语言
-	if !js.LocalJudgeService.IsLanguageSupported(language) {
-		return nil, fmt.Errorf("不支持的编程语言: %s", language)
+	if !js.LocalJudgeService.IsLanguageSupported(task.Language) {
+		return nil, userError("不支持的编程语言: %s", task.Language)
 	}
 
-	inputs := make([]string, 0, len(testCases))
-	expectedList := make([]string, 0, len(testCases))
-	ctx := context.Background()
-	for _, tc := range testCases {
-		input, expected, err := js.loadTestCaseIO(ctx, tc)
-		if err != nil {
-			return nil, err
-		}
-		inputs = append(inputs, input)
-		expectedList = append(expectedList, expected)
-	}
+	return js.LocalJudgeService.JudgeBatch(task)
+}
 
-	batch, err := js.LocalJudgeService.JudgeBatch(code, inputs, language)
-	if err != nil {
-		return nil, err
-	}
-	if len(batch) != len(inputs) {
-		return nil, fmt.Errorf("本地评测结果数量不匹配: got=%d want=%d", len(batch), len(inputs))
-	}
+// maxStderrSize 每个测试用例保存的 stderr 最大字节数
+const maxStderrSize = 4096
 
-	results := make([]models.TestCaseResult, 0, len(batch))
-	for i := range batch {
-		r := batch[i]
-		r.Input = inputs[i]
-		r.ExpectedOutput = normalizeOutput(expectedList[i])
-		r.IsCorrect = strings.TrimSpace(normalizeOutput(r.ActualOutput)) == r.ExpectedOutput
-		results = append(results, r)
+// truncateOutput 截断过长的输出，避免结果 JSON 过大
+func truncateOutput(s string, max int) string {
+	if len(s) <= max {
+		return s
 	}
+	return s[:max] + "...[输出被截断]"
+}
 
-	return results, nil
+// signalName 将信号编号转换为可读名称
+func signalName(sig int) string {
+	names := map[int]string{
+		1: "SIGHUP", 2: "SIGINT", 4: "SIGILL", 6: "SIGABRT", 7: "SIGBUS", 8: "SIGFPE",
+		9: "SIGKILL", 11: "SIGSEGV", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM",
+		24: "SIGXCPU", 25: "SIGXFSZ",
+	}
+	if name, ok := names[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", sig)
 }
 
 // normalizeOutput 标准化输出格式
@@ -241,59 +517,33 @@ func normalizeOutput(s string) string {
 }
 
 // executeRemoteJudgement 执行远程API评测 (Go-Judge)
-func (js *JudgeService) executeRemoteJudgement(code string, testCases []models.TestCase) ([]models.TestCaseResult, error) {
+func (js *JudgeService) executeRemoteJudgement(task JudgeTask) ([]models.TestCaseResult, error) {
 	if js.GoJudgeClient == nil {
-		return nil, fmt.Errorf("go-judge client is not initialized")
+		return nil, systemError(SysErrSandbox, "go-judge client is not initialized")
 	}
 
-	// 1. 准备输入数据
-	inputs := make([]string, 0, len(testCases))
-	expectedList := make([]string, 0, len(testCases))
-	ctx := context.Background()
-
-	for _, tc := range testCases {
-		input, expected, err := js.loadTestCaseIO(ctx, tc)
-		if err != nil {
-			return nil, err
+	// 任务未指定限制（如生成输出、运行校验器）时使用配置的上限
+	if task.MemoryLimitMB == 0 {
+		task.MemoryLimitMB = int64(js.Config.GoJudge.MaxMemory)
+		if task.MemoryLimitMB == 0 {
+			task.MemoryLimitMB = 256
 		}
-		inputs = append(inputs, input)
-		expectedList = append(expectedList, expected)
 	}
-
-	// 2. 检测语言
-	language := js.detectLanguage(code)
-
-	// 3. 调用 Go-Judge (批量执行)
-	memLimit := int64(js.Config.GoJudge.MaxMemory)
-	if memLimit == 0 {
-		memLimit = 256
-	}
-	timeLimit := int64(js.Config.GoJudge.MaxTime)
-	if timeLimit == 0 {
-		timeLimit = 5000
+	if task.TimeLimitMs == 0 {
+		task.TimeLimitMs = int64(js.Config.GoJudge.MaxTime)
+		if task.TimeLimitMs == 0 {
+			task.TimeLimitMs = 5000
+		}
 	}
 
-	results, err := js.GoJudgeClient.Run(code, language, inputs, timeLimit, memLimit)
+	// 调用 Go-Judge (批量执行)，Runtime/Memory/ActualOutput 及异常结论由客户端填好
+	results, err := js.GoJudgeClient.Run(task)
 	if err != nil {
-		return nil, fmt.Errorf("go-judge execution failed: %w", err)
-	}
-
-	// 4. 比对结果
-	for i := range results {
-		// GoJudgeClient 已经填好了 Runtime, Memory, ActualOutput (maybe error message)
-		// 我们需要比对 ExpectedOutput
-		if results[i].ActualOutput == "Time Limit Exceeded" ||
-			results[i].ActualOutput == "Memory Limit Exceeded" ||
-			strings.HasPrefix(results[i].ActualOutput, "Runtime Error") ||
-			strings.HasPrefix(results[i].ActualOutput, "Compile Error") ||
-			strings.HasPrefix(results[i].ActualOutput, "Error") {
-			results[i].IsCorrect = false
-		} else {
-			results[i].ExpectedOutput = normalizeOutput(expectedList[i])
-			results[i].ActualOutput = strings.TrimSpace(results[i].ActualOutput)
-			// 标准化 Output
-			results[i].IsCorrect = strings.TrimSpace(normalizeOutput(results[i].ActualOutput)) == results[i].ExpectedOutput
+		var ue *UserError
+		if errors.As(err, &ue) {
+			return nil, err
 		}
+		return nil, systemError(SysErrNetwork, "go-judge execution failed: %w", err)
 	}
 
 	return results, nil
@@ -321,12 +571,23 @@ func (js *JudgeService) detectLanguage(code string) string {
 }
 
 // getTestCases 获取题目测试用例
-func (js *JudgeService) getTestCases(questionID int) ([]models.TestCase, error) {
+func (js *JudgeService) getTestCases(questionID int, judgeMode string) ([]models.TestCase, error) {
 	var testCases []models.TestCase
 
+	if judgeMode == models.JudgeModeSample {
+		if err := js.DB.Where("question_id = ? AND is_sample = ?", questionID, true).
+			Order("sample_order ASC, id ASC").Find(&testCases).Error; err != nil {
+			return nil, systemError(SysErrDatabase, "数据库查询失败: %w", err)
+		}
+		if len(testCases) == 0 {
+			return nil, systemError(SysErrTestData, "题目没有可用的样例测试用例")
+		}
+		return testCases, nil
+	}
+
 	if err := js.DB.Where("question_id = ? AND is_hidden = ?", questionID, false).
 		Find(&testCases).Error; err != nil {
-		return nil, fmt.Errorf("数据库查询失败: %w", err)
+		return nil, systemError(SysErrDatabase, "数据库查询失败: %w", err)
 	}
 
 	if len(testCases) != 0 {
@@ -334,12 +595,12 @@ func (js *JudgeService) getTestCases(questionID int) ([]models.TestCase, error)
 	}
 
 	if js.OSSClient == nil || js.OSSBucket == "" {
-		return nil, fmt.Errorf("题目没有可用的测试用例")
+		return nil, systemError(SysErrTestData, "题目没有可用的测试用例")
 	}
 
 	var question models.Question
 	if err := js.DB.Where("id = ?", questionID).First(&question).Error; err != nil {
-		return nil, fmt.Errorf("题目没有可用的测试用例")
+		return nil, systemError(SysErrTestData, "题目没有可用的测试用例")
 	}
 
 	prefix := fmt.Sprintf("problems/%d/", question.QuestionNumber)
@@ -347,7 +608,7 @@ func (js *JudgeService) getTestCases(questionID int) ([]models.TestCase, error)
 	ctx := context.Background()
 	objects, err := js.OSSClient.ListObjects(ctx, js.OSSBucket, prefix, true)
 	if err != nil {
-		return nil, fmt.Errorf("获取测试用例失败: %w", err)
+		return nil, systemError(SysErrStorage, "获取测试用例失败: %w", err)
 	}
 
 	type pair struct {
@@ -410,7 +671,7 @@ func (js *JudgeService) getTestCases(questionID int) ([]models.TestCase, error)
 	}
 
 	if len(testCases) == 0 {
-		return nil, fmt.Errorf("题目没有可用的测试用例")
+		return nil, systemError(SysErrTestData, "题目没有可用的测试用例")
 	}
 
 	return testCases, nil
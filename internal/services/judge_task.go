@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"regexp"
+
+	"dachuang/internal/models"
+)
+
+// defaultOutputLimit 未指定输出上限时使用的默认值（字节）
+const defaultOutputLimit int64 = 64 * 1024
+
+// JudgeTask 一次批量评测任务，由 JudgeService 构造后交给具体执行器（go-judge / docker / host）
+type JudgeTask struct {
+	Code     string
+	Language string
+	Inputs   []string
+
+	// OutputLimits 每个测试用例的 stdout 上限（字节），超出即判为 OLE
+	OutputLimits []int64
+
+	// Args 每个测试用例追加的命令行参数（可选），数据生成器用它接收参数与随机种子
+	Args [][]string
+
+	// 文件 IO（可选）：设置后测试输入写入沙箱中的 InputFile，程序写出的 OutputFile 代替 stdout 参与比对
+	InputFile  string
+	OutputFile string
+
+	// 多文件提交（可选）：设置后忽略 Code，Files 写入项目目录，先执行 BuildCommand 构建再运行
+	// 构建产物与运行方式见 projectRunArgs
+	Files        []SourceFile
+	BuildCommand string
+
+	// 时间/内存限制，评测题目时取题目的限制；0 表示使用执行器自身的默认配置（本地执行器始终使用自身配置）
+	TimeLimitMs   int64
+	MemoryLimitMB int64
+
+	// 进度回调（可选），执行器在阶段切换和每个用例结束时调用
+	OnStage func(stage string)
+	OnCase  func(i int, r models.TestCaseResult)
+
+	// Ctx 取消评测（可选），取消后执行器终止正在运行的进程、清理沙箱并返回 ctx.Err()
+	Ctx context.Context
+}
+
+// context 返回任务的上下文，未设置时不可取消
+func (t *JudgeTask) context() context.Context {
+	if t.Ctx != nil {
+		return t.Ctx
+	}
+	return context.Background()
+}
+
+// stage 通知进入新的评测阶段（compiling / running）
+func (t *JudgeTask) stage(stage string) {
+	if t.OnStage != nil {
+		t.OnStage(stage)
+	}
+}
+
+// caseDone 通知第 i 个测试用例已运行结束
+func (t *JudgeTask) caseDone(i int, r models.TestCaseResult) {
+	if t.OnCase != nil {
+		t.OnCase(i, r)
+	}
+}
+
+// outputLimit 返回第 i 个测试用例的输出上限，未配置时使用 fallback
+func (t *JudgeTask) outputLimit(i int, fallback int64) int64 {
+	if i < len(t.OutputLimits) && t.OutputLimits[i] > 0 {
+		return t.OutputLimits[i]
+	}
+	if fallback > 0 {
+		return fallback
+	}
+	return defaultOutputLimit
+}
+
+// isProject 是否为多文件提交
+func (t *JudgeTask) isProject() bool {
+	return len(t.Files) > 0
+}
+
+// args 返回第 i 个测试用例的命令行参数
+func (t *JudgeTask) args(i int) []string {
+	if i < len(t.Args) {
+		return t.Args[i]
+	}
+	return nil
+}
+
+// stdin 返回第 i 个测试用例应写入 stdin 的内容，输入走文件时为空
+func (t *JudgeTask) stdin(input string) string {
+	if t.InputFile != "" {
+		return ""
+	}
+	return input
+}
+
+// ioFileNamePattern 文件 IO 的文件名：不含路径分隔符的普通文件名
+var ioFileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,63}$`)
+
+// reservedIOFileNames 执行器在沙箱中使用的文件名，不能作为题目的输入输出文件
+var reservedIOFileNames = map[string]bool{
+	"main": true, "main.exe": true, "main.cpp": true, "main.go": true, "main.py": true,
+	"Main.java": true, "Main.class": true, "stdout": true, "stderr": true,
+}
+
+// ValidIOFileName 检查文件 IO 的文件名是否可用
+func ValidIOFileName(name string) bool {
+	return ioFileNamePattern.MatchString(name) && !reservedIOFileNames[name]
+}
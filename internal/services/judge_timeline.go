@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"dachuang/internal/models"
+)
+
+// 评测时间线阶段
+const (
+	TimelineQueue   = "queue"   // 入队到出队
+	TimelineFetch   = "fetch"   // 读取测试数据与提交文件
+	TimelineCache   = "cache"   // 命中结果缓存
+	TimelineCompile = "compile" // 编译
+	TimelineRun     = "run"     // 运行全部测试点
+	TimelineCase    = "case"    // 单个测试点的运行耗时
+	TimelineCompare = "compare" // 比对输出
+	TimelineSave    = "save"    // 保存评测结果
+)
+
+// Timeline 记录一次评测各阶段的起止时间，nil 时所有方法均为空操作
+type Timeline struct {
+	stages []models.TimelineStage
+	open   map[string]time.Time
+}
+
+// NewTimeline 创建空的时间线
+func NewTimeline() *Timeline {
+	return &Timeline{open: make(map[string]time.Time)}
+}
+
+// Add 追加一个已结束的阶段
+func (t *Timeline) Add(stage string, start, end time.Time) {
+	if t == nil {
+		return
+	}
+	t.stages = append(t.stages, models.TimelineStage{
+		Stage:      stage,
+		Start:      start,
+		DurationMs: float64(end.Sub(start).Microseconds()) / 1000,
+	})
+}
+
+// Begin 标记阶段开始
+func (t *Timeline) Begin(stage string) {
+	if t == nil {
+		return
+	}
+	t.open[stage] = time.Now()
+}
+
+// End 结束由 Begin 开始的阶段，未开始的阶段忽略
+func (t *Timeline) End(stage string) {
+	if t == nil {
+		return
+	}
+	start, ok := t.open[stage]
+	if !ok {
+		return
+	}
+	delete(t.open, stage)
+	t.Add(stage, start, time.Now())
+}
+
+// Case 记录第 n 个测试点的运行耗时，采用评测后端测得的运行时间，
+// 这样批量执行全部测试点的后端也能得到逐个测试点的耗时
+func (t *Timeline) Case(n int, runtimeMs int64) {
+	if t == nil {
+		return
+	}
+	t.stages = append(t.stages, models.TimelineStage{
+		Stage:      TimelineCase,
+		Case:       n,
+		Start:      time.Now(),
+		DurationMs: float64(runtimeMs),
+	})
+}
+
+// JSON 序列化已结束的阶段，用于写入 Submission.Timeline
+func (t *Timeline) JSON() string {
+	if t == nil || len(t.stages) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(t.stages)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseTimeline 解析 Submission.Timeline，格式错误时返回空
+func ParseTimeline(s string) []models.TimelineStage {
+	var stages []models.TimelineStage
+	if s == "" || json.Unmarshal([]byte(s), &stages) != nil {
+		return nil
+	}
+	return stages
+}
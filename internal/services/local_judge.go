@@ -1,8 +1,10 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -10,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"dachuang/internal/config"
@@ -30,28 +33,20 @@ func NewLocalJudgeService(cfg *config.LocalJudgeConfig) *LocalJudgeService {
 	}
 }
 
-func (ljs *LocalJudgeService) JudgeBatch(code string, inputs []string, language string) ([]models.TestCaseResult, error) {
+// JudgeBatch 批量评测：编译一次，依次运行所有测试用例
+func (ljs *LocalJudgeService) JudgeBatch(task JudgeTask) ([]models.TestCaseResult, error) {
 	executor := strings.ToLower(strings.TrimSpace(ljs.Config.Executor))
 	if executor == "docker" {
-		return ljs.judgeBatchDocker(code, inputs, language)
+		return ljs.judgeBatchDocker(task)
 	}
-
-	results := make([]models.TestCaseResult, 0, len(inputs))
-	for _, input := range inputs {
-		r, err := ljs.JudgeCode(code, input, language)
-		if err != nil {
-			r = &models.TestCaseResult{Input: input, ActualOutput: fmt.Sprintf("Error: %v", err), IsCorrect: false, Runtime: 0, MemoryUsage: 0}
-		}
-		results = append(results, *r)
-	}
-	return results, nil
+	return ljs.judgeBatchHost(task)
 }
 
 // JudgeCode 本地评测代码（兼容旧接口：单 case）
 func (ljs *LocalJudgeService) JudgeCode(code, input, language string) (*models.TestCaseResult, error) {
 	log.Print("start judge code")
 
-	results, err := ljs.JudgeBatch(code, []string{input}, language)
+	results, err := ljs.JudgeBatch(JudgeTask{Code: code, Language: language, Inputs: []string{input}})
 	if err != nil {
 		return nil, err
 	}
@@ -198,18 +193,105 @@ func (ljs *LocalJudgeService) dockerRemove(ctx context.Context, containerName st
 	_ = exec.CommandContext(ctx, "docker", "rm", "-f", containerName).Run()
 }
 
-func (ljs *LocalJudgeService) dockerExec(ctx context.Context, containerName string, stdin string, args ...string) (string, error) {
+// dockerExec 在容器内执行命令，stdout 超过 outputLimit 字节时立即终止
+func (ljs *LocalJudgeService) dockerExec(ctx context.Context, containerName string, stdin string, outputLimit int64, args ...string) (execResult, error) {
 	base := []string{"exec", "-i", containerName}
 	base = append(base, args...)
-	cmd := exec.CommandContext(ctx, "docker", base...)
+	return runLimited(ctx, "", stdin, outputLimit, "docker", base...)
+}
+
+// dockerKillAll 杀死容器内除 1 号进程（保持容器运行的循环）外的全部进程
+// 输出超限或超时只终止了宿主机上的 docker exec 客户端，容器内的进程仍会继续运行
+func (ljs *LocalJudgeService) dockerKillAll(containerName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exec.CommandContext(ctx, "docker", "exec", containerName, "sh", "-c", "kill -9 -1 2>/dev/null; true").Run(); err != nil {
+		log.Printf("清理容器内进程失败 container=%s err=%v", containerName, err)
+	}
+}
+
+// compileOutputLimit 编译器输出的读取上限
+const compileOutputLimit int64 = 1024 * 1024
+
+// execResult 一次进程执行的结果
+type execResult struct {
+	Stdout         string
+	Stderr         string
+	ExitCode       int
+	Signal         int
+	OutputExceeded bool // stdout 是否超出上限
+}
+
+// limitedBuffer 限制写入字节数的缓冲区
+// 超出上限后丢弃后续数据并触发 onExceed（用于尽早杀死进程），而不是读完全部输出后再截断
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	onExceed func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return len(p), nil
+	}
+	remain := b.limit - int64(b.buf.Len())
+	if int64(len(p)) > remain {
+		b.buf.Write(p[:max(remain, 0)])
+		b.exceeded = true
+		if b.onExceed != nil {
+			b.onExceed()
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// runLimited 执行命令，分别收集 stdout/stderr；stdout 超限时取消上下文终止进程
+func runLimited(ctx context.Context, dir string, stdin string, outputLimit int64, name string, args ...string) (execResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
 	if stdin != "" {
 		cmd.Stdin = strings.NewReader(stdin)
 	}
-	out, err := cmd.CombinedOutput()
-	return string(out), err
+	stdout := &limitedBuffer{limit: outputLimit, onExceed: cancel}
+	stderr := &limitedBuffer{limit: maxStderrSize * 4}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	exitCode, sig := processExitStatus(cmd.ProcessState)
+	return execResult{
+		Stdout:         stdout.String(),
+		Stderr:         stderr.String(),
+		ExitCode:       exitCode,
+		Signal:         sig,
+		OutputExceeded: stdout.exceeded,
+	}, err
+}
+
+// processExitStatus 解析进程退出状态，被信号终止时退出码按 shell 约定记为 128+信号编号
+func processExitStatus(state *os.ProcessState) (int, int) {
+	if state == nil {
+		return -1, 0
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		sig := int(ws.Signal())
+		return 128 + sig, sig
+	}
+	return state.ExitCode(), 0
 }
 
-func (ljs *LocalJudgeService) judgeBatchDocker(code string, inputs []string, language string) ([]models.TestCaseResult, error) {
+func (ljs *LocalJudgeService) judgeBatchDocker(task JudgeTask) ([]models.TestCaseResult, error) {
+	inputs, language := task.Inputs, task.Language
+
 	image := ljs.dockerImageForLanguage(language)
 	if image == "" {
 		return nil, fmt.Errorf("不支持的语言: %s", language)
@@ -221,9 +303,9 @@ func (ljs *LocalJudgeService) judgeBatchDocker(code string, inputs []string, lan
 	}
 	defer ljs.cleanupSandbox(sandboxPath)
 
-	codeFile, err := ljs.writeCodeFile(sandboxPath, code, language)
+	filename, err := ljs.writeTaskFiles(sandboxPath, &task)
 	if err != nil {
-		return nil, fmt.Errorf("写入代码文件失败: %w", err)
+		return nil, err
 	}
 
 	mount, err := ljs.dockerMountSpec(sandboxPath)
@@ -231,8 +313,10 @@ func (ljs *LocalJudgeService) judgeBatchDocker(code string, inputs []string, lan
 		return nil, err
 	}
 
+	// 取消评测时 docker 命令随 ctx 终止，容器与沙箱目录由 defer 清理
+	ctx := task.context()
 	containerName := fmt.Sprintf("oj_%d", time.Now().UnixNano())
-	runCtx, cancelRun := context.WithTimeout(context.Background(), 30*time.Second)
+	runCtx, cancelRun := context.WithTimeout(ctx, 30*time.Second)
 	defer cancelRun()
 	if err := ljs.dockerRunDetached(runCtx, containerName, image, mount); err != nil {
 		return nil, err
@@ -240,28 +324,39 @@ func (ljs *LocalJudgeService) judgeBatchDocker(code string, inputs []string, lan
 	defer ljs.dockerRemove(context.Background(), containerName)
 
 	compileErr := ""
-	filename := filepath.Base(codeFile)
+	compileMsg := ""
 	compileTimeout := time.Duration(ljs.Config.MaxTime)
 	if compileTimeout <= 0 {
 		compileTimeout = 5
 	}
 	compileTimeout = (compileTimeout * time.Second) + 15*time.Second
-	cctx, cancelCompile := context.WithTimeout(context.Background(), compileTimeout)
+	cctx, cancelCompile := context.WithTimeout(ctx, compileTimeout)
 	defer cancelCompile()
-	switch strings.ToLower(strings.TrimSpace(language)) {
-	case "go":
-		_, err = ljs.dockerExec(cctx, containerName, "", "go", "build", "-o", "main", filename)
-	case "cpp":
-		_, err = ljs.dockerExec(cctx, containerName, "", "g++", "-O2", "-std=c++17", "-o", "main", filename)
-	case "java":
-		_, err = ljs.dockerExec(cctx, containerName, "", "javac", filename)
-	case "python":
+	task.stage(StageCompiling)
+	var compiled execResult
+	switch lang := strings.ToLower(strings.TrimSpace(language)); {
+	case task.isProject():
+		// 多文件项目执行配置的构建命令，无构建产物的语言（python）直接运行
+		if artifact, _ := projectRunArgs(lang); artifact != "" {
+			compiled, err = ljs.dockerExec(cctx, containerName, "", compileOutputLimit, "sh", "-c", task.BuildCommand)
+		}
+	case lang == "go":
+		compiled, err = ljs.dockerExec(cctx, containerName, "", compileOutputLimit, "go", "build", "-o", "main", filename)
+	case lang == "cpp":
+		compiled, err = ljs.dockerExec(cctx, containerName, "", compileOutputLimit, "g++", "-O2", "-std=c++17", "-o", "main", filename)
+	case lang == "java":
+		compiled, err = ljs.dockerExec(cctx, containerName, "", compileOutputLimit, "javac", filename)
+	case lang == "python":
 		err = nil
 	default:
 		err = fmt.Errorf("不支持的语言: %s", language)
 	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		compileErr = fmt.Sprintf("Compile Error: %v", err)
+		compileMsg = truncateOutput(strings.TrimSpace(compiled.Stderr+"\n"+compiled.Stdout), maxStderrSize)
 	}
 
 	maxOutput := ljs.Config.MaxOutputSize
@@ -273,50 +368,236 @@ func (ljs *LocalJudgeService) judgeBatchDocker(code string, inputs []string, lan
 		caseTimeoutSec = 5
 	}
 
+	if compileErr == "" {
+		task.stage(StageRunning)
+	}
 	results := make([]models.TestCaseResult, 0, len(inputs))
-	for _, input := range inputs {
+	for i, input := range inputs {
 		if compileErr != "" {
-			results = append(results, models.TestCaseResult{Input: input, ActualOutput: compileErr, IsCorrect: false, Runtime: 0, MemoryUsage: 0})
+			results = append(results, models.TestCaseResult{Input: input, ActualOutput: compileErr, Verdict: models.VerdictCompileError, Stderr: compileMsg, IsCorrect: false, Runtime: 0, MemoryUsage: 0})
 			continue
 		}
 
 		runArgs := []string{"timeout", "-k", "1s", fmt.Sprintf("%ds", caseTimeoutSec)}
-		switch strings.ToLower(strings.TrimSpace(language)) {
-		case "go", "cpp":
+		switch lang := strings.ToLower(strings.TrimSpace(language)); {
+		case task.isProject():
+			_, projectArgs := projectRunArgs(lang)
+			runArgs = append(runArgs, projectArgs...)
+		case lang == "go", lang == "cpp":
 			runArgs = append(runArgs, "./main")
-		case "python":
+		case lang == "python":
 			runArgs = append(runArgs, "python", "-u", filename)
-		case "java":
+		case lang == "java":
 			runArgs = append(runArgs, "java", "-cp", ".", "Main")
 		}
+		runArgs = append(runArgs, task.args(i)...)
 
+		if err := prepareIOFiles(sandboxPath, &task, input); err != nil {
+			return nil, err
+		}
+		limit := task.outputLimit(i, int64(maxOutput)*1024)
 		start := time.Now()
-		rctx, cancel := context.WithTimeout(context.Background(), time.Duration(caseTimeoutSec+2)*time.Second)
-		out, runErr := ljs.dockerExec(rctx, containerName, input, runArgs...)
+		rctx, cancel := context.WithTimeout(ctx, time.Duration(caseTimeoutSec+2)*time.Second)
+		out, runErr := ljs.dockerExec(rctx, containerName, task.stdin(input), limit, runArgs...)
+		interrupted := out.OutputExceeded || rctx.Err() != nil
 		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// 避免被中断的进程继续占用 CPU 或改写输出文件，影响后续测试点
+		if interrupted {
+			ljs.dockerKillAll(containerName)
+		}
 		runtime := time.Since(start).Milliseconds()
+		if err := collectOutputFile(sandboxPath, &task, limit, &out); err != nil {
+			return nil, err
+		}
 
-		actual := strings.TrimSpace(out)
-		if len(actual) > maxOutput*1024 {
-			actual = actual[:maxOutput*1024] + "...[输出被截断]"
+		exitCode := out.ExitCode
+		r := models.TestCaseResult{
+			Input:        input,
+			ActualOutput: strings.TrimSpace(out.Stdout),
+			Stderr:       truncateOutput(out.Stderr, maxStderrSize),
+			ExitCode:     exitCode,
+			Runtime:      runtime,
+		}
+		switch {
+		case out.OutputExceeded:
+			r.ActualOutput = "Output Limit Exceeded"
+			r.Verdict = models.VerdictOutputLimitExceeded
+		case runErr == nil:
+		// timeout 超时退出码为 124；-k 强制杀死时为 137，需结合耗时判断
+		case exitCode == 124 || (exitCode == 137 && runtime >= int64(caseTimeoutSec)*1000):
+			r.ActualOutput = "Time Limit Exceeded"
+			r.Verdict = models.VerdictTimeLimitExceeded
+		case exitCode > 128:
+			r.Signal = signalName(exitCode - 128)
+			r.ActualOutput = fmt.Sprintf("Runtime Error (Signal %s)", r.Signal)
+			r.Verdict = models.VerdictRuntimeError
+		default:
+			r.ActualOutput = fmt.Sprintf("Runtime Error: %v", runErr)
+			r.Verdict = models.VerdictRuntimeError
 		}
 
-		if runErr != nil {
-			if strings.Contains(actual, "Time") || strings.Contains(actual, "exceeded") {
-				actual = "Time Limit Exceeded"
-			} else if actual == "" {
-				actual = fmt.Sprintf("Runtime Error: %v", runErr)
+		results = append(results, r)
+		task.caseDone(i, r)
+	}
+
+	return results, nil
+}
+
+// judgeBatchHost 直接在宿主机上编译并依次运行测试用例
+func (ljs *LocalJudgeService) judgeBatchHost(task JudgeTask) ([]models.TestCaseResult, error) {
+	sandboxPath, err := ljs.createSandbox()
+	if err != nil {
+		return nil, fmt.Errorf("创建沙箱失败: %w", err)
+	}
+	defer ljs.cleanupSandbox(sandboxPath)
+
+	filename, err := ljs.writeTaskFiles(sandboxPath, &task)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := task.context()
+	results := make([]models.TestCaseResult, 0, len(task.Inputs))
+	task.stage(StageCompiling)
+	var executablePath string
+	if task.isProject() {
+		err = ljs.buildProject(ctx, sandboxPath, &task)
+	} else {
+		executablePath, err = ljs.compileCode(ctx, sandboxPath, filepath.Join(sandboxPath, filename), task.Language)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		msg := truncateOutput(err.Error(), maxStderrSize)
+		for _, input := range task.Inputs {
+			results = append(results, models.TestCaseResult{Input: input, ActualOutput: "Compile Error", Verdict: models.VerdictCompileError, Stderr: msg})
+		}
+		return results, nil
+	}
+
+	task.stage(StageRunning)
+	fallback := int64(ljs.Config.MaxOutputSize) * 1024
+	for i, input := range task.Inputs {
+		if err := prepareIOFiles(sandboxPath, &task, input); err != nil {
+			return nil, err
+		}
+		limit := task.outputLimit(i, fallback)
+		var r *models.TestCaseResult
+		if task.isProject() {
+			_, projectArgs := projectRunArgs(task.Language)
+			r, err = ljs.runCommand(ctx, sandboxPath, task.stdin(input), limit, append(append([]string{}, projectArgs...), task.args(i)...))
+		} else {
+			r, err = ljs.executeCode(ctx, sandboxPath, executablePath, task.stdin(input), task.Language, limit, task.args(i))
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil && task.OutputFile != "" && r.Verdict == "" {
+			var out execResult
+			if err = collectOutputFile(sandboxPath, &task, limit, &out); err == nil {
+				r.ActualOutput = strings.TrimSpace(out.Stdout)
+				if out.OutputExceeded {
+					r.ActualOutput = "Output Limit Exceeded"
+					r.Verdict = models.VerdictOutputLimitExceeded
+				}
 			}
 		}
+		if err == nil {
+			r.Input = input
+		}
+		if err != nil {
+			r = &models.TestCaseResult{Input: input, ActualOutput: fmt.Sprintf("Error: %v", err), Verdict: models.VerdictRuntimeError}
+		}
+		results = append(results, *r)
+		task.caseDone(i, *r)
+	}
+	return results, nil
+}
 
-		results = append(results, models.TestCaseResult{Input: input, ActualOutput: actual, IsCorrect: false, Runtime: runtime, MemoryUsage: 0})
+// writeTaskFiles 写入单文件代码或多文件项目，返回单文件时的源文件名
+func (ljs *LocalJudgeService) writeTaskFiles(sandboxPath string, task *JudgeTask) (string, error) {
+	if task.isProject() {
+		if _, args := projectRunArgs(task.Language); args == nil {
+			return "", fmt.Errorf("不支持的语言: %s", task.Language)
+		}
+		if err := writeSourceFiles(sandboxPath, task.Files); err != nil {
+			return "", fmt.Errorf("写入项目文件失败: %w", err)
+		}
+		return "", nil
 	}
+	codeFile, err := ljs.writeCodeFile(sandboxPath, task.Code, task.Language)
+	if err != nil {
+		return "", fmt.Errorf("写入代码文件失败: %w", err)
+	}
+	return filepath.Base(codeFile), nil
+}
 
-	return results, nil
+// buildProject 在沙箱目录中执行多文件项目的构建命令（python 无需构建）
+func (ljs *LocalJudgeService) buildProject(ctx context.Context, sandboxPath string, task *JudgeTask) error {
+	artifact, _ := projectRunArgs(task.Language)
+	if artifact == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	out, err := runLimited(ctx, sandboxPath, "", compileOutputLimit, "sh", "-c", task.BuildCommand)
+	if err != nil {
+		return fmt.Errorf("编译错误: %s", strings.TrimSpace(out.Stderr+"\n"+out.Stdout))
+	}
+	if _, err := os.Stat(filepath.Join(sandboxPath, artifact)); err != nil {
+		return fmt.Errorf("编译产物不存在: %s", artifact)
+	}
+	return nil
+}
+
+// prepareIOFiles 文件 IO 题目：写入本用例的输入文件，并删除上一个用例留下的输出文件
+func prepareIOFiles(dir string, task *JudgeTask, input string) error {
+	if task.InputFile != "" {
+		if err := os.WriteFile(filepath.Join(dir, task.InputFile), []byte(input), 0644); err != nil {
+			return fmt.Errorf("写入输入文件失败: %w", err)
+		}
+	}
+	if task.OutputFile != "" {
+		if err := os.Remove(filepath.Join(dir, task.OutputFile)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清理输出文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// collectOutputFile 文件 IO 题目：用输出文件的内容代替 stdout，最多读取 limit+1 字节；文件不存在视为空输出
+func collectOutputFile(dir string, task *JudgeTask, limit int64, out *execResult) error {
+	if task.OutputFile == "" {
+		return nil
+	}
+	out.Stdout = ""
+	f, err := os.Open(filepath.Join(dir, task.OutputFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取输出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return fmt.Errorf("读取输出文件失败: %w", err)
+	}
+	if int64(len(b)) > limit {
+		out.OutputExceeded = true
+		b = b[:limit]
+	}
+	out.Stdout = string(b)
+	return nil
 }
 
 // compileCode 编译代码
-func (ljs *LocalJudgeService) compileCode(sandboxPath, codeFile, language string) (string, error) {
+func (ljs *LocalJudgeService) compileCode(ctx context.Context, sandboxPath, codeFile, language string) (string, error) {
 	var cmd *exec.Cmd
 	var executablePath string
 
@@ -336,14 +617,14 @@ func (ljs *LocalJudgeService) compileCode(sandboxPath, codeFile, language string
 	case "go":
 		executablePath = filepath.Join(sandboxPath, "main.exe")
 		// 使用相对路径编译
-		cmd = exec.Command("go", "build", "-o", "main.exe", filename)
+		cmd = exec.CommandContext(ctx, "go", "build", "-o", "main.exe", filename)
 	case "cpp":
 		executablePath = filepath.Join(sandboxPath, "main.exe")
 		// 使用相对路径编译
-		cmd = exec.Command("g++", "-o", "main.exe", filename)
+		cmd = exec.CommandContext(ctx, "g++", "-o", "main.exe", filename)
 	case "java":
 		// Java编译后的类文件
-		cmd = exec.Command("javac", filename)
+		cmd = exec.CommandContext(ctx, "javac", filename)
 		executablePath = filepath.Join(sandboxPath, "Main.class")
 	case "python":
 		// Python不需要编译
@@ -377,7 +658,7 @@ func (ljs *LocalJudgeService) compileCode(sandboxPath, codeFile, language string
 }
 
 // executeCode 执行代码
-func (ljs *LocalJudgeService) executeCode(sandboxPath, executablePath, input, language string) (*models.TestCaseResult, error) {
+func (ljs *LocalJudgeService) executeCode(ctx context.Context, sandboxPath, executablePath, input, language string, outputLimit int64, args []string) (*models.TestCaseResult, error) {
 	var cmd *exec.Cmd
 
 	log.Printf("开始执行，沙箱路径: %s", sandboxPath)
@@ -389,8 +670,8 @@ func (ljs *LocalJudgeService) executeCode(sandboxPath, executablePath, input, la
 
 	switch language {
 	case "go", "cpp":
-		// 使用相对路径执行
-		cmd = exec.Command(".\\" + executableName)
+		// 使用相对路径执行（相对于沙箱工作目录）
+		cmd = exec.Command("." + string(filepath.Separator) + executableName)
 	case "python":
 		// Python使用相对路径
 		pythonFile := filepath.Base(executablePath)
@@ -400,44 +681,55 @@ func (ljs *LocalJudgeService) executeCode(sandboxPath, executablePath, input, la
 	default:
 		return nil, fmt.Errorf("不支持的语言: %s", language)
 	}
+	cmd.Args = append(cmd.Args, args...)
 
 	log.Printf("执行命令: %v", cmd.Args)
+	return ljs.runCommand(ctx, sandboxPath, input, outputLimit, cmd.Args)
+}
 
+// runCommand 在沙箱目录中运行一个测试用例并映射评测结果，parent 被取消时返回其错误
+func (ljs *LocalJudgeService) runCommand(parent context.Context, sandboxPath, input string, outputLimit int64, args []string) (*models.TestCaseResult, error) {
 	// 创建上下文以控制超时
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(ljs.Config.MaxTime)*time.Second)
+	ctx, cancel := context.WithTimeout(parent, time.Duration(ljs.Config.MaxTime)*time.Second)
 	defer cancel()
 
-	// 使用上下文执行命令
-	cmd = exec.CommandContext(ctx, cmd.Args[0], cmd.Args[1:]...)
-	cmd.Dir = sandboxPath
-	cmd.Stdin = strings.NewReader(input)
-
-	log.Printf("最终执行命令: %v", cmd.Args)
-	log.Printf("工作目录: %s", cmd.Dir)
+	log.Printf("工作目录: %s", sandboxPath)
 
+	// 使用上下文执行命令，stdout 边读边计数
 	startTime := time.Now()
-	output, err := cmd.CombinedOutput()
+	out, err := runLimited(ctx, sandboxPath, input, outputLimit, args[0], args[1:]...)
 	runtime := time.Since(startTime).Milliseconds()
+	if parent.Err() != nil {
+		return nil, parent.Err()
+	}
 
 	result := &models.TestCaseResult{
 		Input:        input,
-		ActualOutput: strings.TrimSpace(string(output)),
+		ActualOutput: strings.TrimSpace(out.Stdout),
+		Stderr:       truncateOutput(out.Stderr, maxStderrSize),
+		ExitCode:     out.ExitCode,
 		Runtime:      runtime,
 		MemoryUsage:  0, // 简单实现，暂不统计内存使用
 	}
 
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			result.ActualOutput = "Time Limit Exceeded"
-		} else {
-			result.ActualOutput = fmt.Sprintf("Runtime Error: %v", err)
-		}
-		result.IsCorrect = false
+	switch {
+	case out.OutputExceeded:
+		result.ActualOutput = "Output Limit Exceeded"
+		result.Verdict = models.VerdictOutputLimitExceeded
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		result.ActualOutput = "Time Limit Exceeded"
+		result.Verdict = models.VerdictTimeLimitExceeded
+	case out.Signal != 0:
+		result.Signal = signalName(out.Signal)
+		result.ActualOutput = fmt.Sprintf("Runtime Error (Signal %s)", result.Signal)
+		result.Verdict = models.VerdictRuntimeError
+	default:
+		result.ActualOutput = fmt.Sprintf("Runtime Error: %v", err)
+		result.Verdict = models.VerdictRuntimeError
 	}
-
-	// 检查输出大小限制
-	if len(result.ActualOutput) > ljs.Config.MaxOutputSize*1024 {
-		result.ActualOutput = result.ActualOutput[:ljs.Config.MaxOutputSize*1024] + "...[输出被截断]"
+	if result.Verdict != "" {
+		result.IsCorrect = false
 	}
 
 	return result, nil
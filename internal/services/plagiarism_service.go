@@ -0,0 +1,253 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"dachuang/internal/models"
+	"dachuang/internal/plagiarism"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPlagiarismThreshold 默认相似度阈值
+	DefaultPlagiarismThreshold = 0.6
+	// maxReportPairs 单份报告最多保留的代码对数量（按相似度降序）
+	maxReportPairs = 1000
+)
+
+// PlagiarismService 代码查重服务
+type PlagiarismService struct {
+	DB *gorm.DB
+}
+
+// NewPlagiarismService 创建查重服务
+func NewPlagiarismService(db *gorm.DB) *PlagiarismService {
+	return &PlagiarismService{DB: db}
+}
+
+// StartForQuestion 创建单题查重报告并在后台执行
+func (ps *PlagiarismService) StartForQuestion(questionID int, threshold float64, createdBy string) (*models.PlagiarismReport, error) {
+	report := &models.PlagiarismReport{
+		Scope:      models.PlagiarismScopeProblem,
+		QuestionID: questionID,
+		Threshold:  threshold,
+		Status:     models.PlagiarismRunning,
+		CreatedBy:  createdBy,
+	}
+	if err := ps.DB.Create(report).Error; err != nil {
+		return nil, fmt.Errorf("创建查重报告失败: %w", err)
+	}
+
+	go ps.run(report, []int{questionID})
+	return report, nil
+}
+
+// StartForContest 创建比赛查重报告并在后台执行，只比对该比赛中（含虚拟参赛）的提交
+func (ps *PlagiarismService) StartForContest(contestID uint, threshold float64, createdBy string) (*models.PlagiarismReport, error) {
+	var questionIDs []int
+	if err := ps.DB.Model(&models.ContestProblem{}).Where("contest_id = ?", contestID).
+		Pluck("question_id", &questionIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询比赛题目失败: %w", err)
+	}
+	if len(questionIDs) == 0 {
+		return nil, fmt.Errorf("比赛中没有题目")
+	}
+
+	report := &models.PlagiarismReport{
+		Scope:     models.PlagiarismScopeContest,
+		ContestID: contestID,
+		Threshold: threshold,
+		Status:    models.PlagiarismRunning,
+		CreatedBy: createdBy,
+	}
+	if err := ps.DB.Create(report).Error; err != nil {
+		return nil, fmt.Errorf("创建查重报告失败: %w", err)
+	}
+
+	go ps.run(report, questionIDs)
+	return report, nil
+}
+
+// run 执行查重并保存结果
+func (ps *PlagiarismService) run(report *models.PlagiarismReport, questionIDs []int) {
+	updates := map[string]interface{}{}
+	err := func() error {
+		subs, excluded, err := ps.acceptedSubmissions(questionIDs, report.ContestID)
+		if err != nil {
+			return err
+		}
+		pairs, clusters := AnalyzePlagiarism(subs, report.Threshold)
+
+		pairsJSON, err := json.Marshal(pairs)
+		if err != nil {
+			return err
+		}
+		clustersJSON, err := json.Marshal(clusters)
+		if err != nil {
+			return err
+		}
+		excludedJSON, err := json.Marshal(excluded)
+		if err != nil {
+			return err
+		}
+		updates["status"] = models.PlagiarismCompleted
+		updates["submission_count"] = len(subs)
+		updates["pair_count"] = len(pairs)
+		updates["pairs"] = string(pairsJSON)
+		updates["clusters"] = string(clustersJSON)
+		updates["excluded_count"] = len(excluded)
+		updates["excluded"] = string(excludedJSON)
+		return nil
+	}()
+	if err != nil {
+		log.Printf("查重失败 report=%d err=%v", report.ID, err)
+		updates = map[string]interface{}{"status": models.PlagiarismFailed, "error_msg": err.Error()}
+	}
+
+	if err := ps.DB.Model(&models.PlagiarismReport{}).Where("id = ?", report.ID).Updates(updates).Error; err != nil {
+		log.Printf("保存查重报告失败 report=%d err=%v", report.ID, err)
+	}
+}
+
+// acceptedSubmissions 获取题目下的通过提交，每个用户每种语言只保留最近一次；contestID 非 0 时只取该比赛中的提交
+// 多文件提交的源码存放在 OSS 中，暂不参与比对，其 ID 作为 excluded 返回并记入报告
+func (ps *PlagiarismService) acceptedSubmissions(questionIDs []int, contestID uint) (subs []models.Submission, excluded []string, err error) {
+	query := ps.DB.Where("question_id IN ? AND judge_mode = ? AND verdict = ?", questionIDs, models.JudgeModeFull, models.VerdictAccepted)
+	if contestID != 0 {
+		query = query.Where("contest_id = ?", contestID)
+	}
+	var all []models.Submission
+	if err := query.Order("created_at DESC").Find(&all).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询提交记录失败: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	subs = make([]models.Submission, 0, len(all))
+	excluded = []string{}
+	for _, s := range all {
+		key := fmt.Sprintf("%d|%s|%s", s.QuestionID, s.UserID, s.Language)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if s.FileCount > 0 {
+			excluded = append(excluded, s.ID)
+			continue
+		}
+		subs = append(subs, s)
+	}
+	return subs, excluded, nil
+}
+
+// AnalyzePlagiarism 对同题同语言的提交两两比对，返回相似度不低于阈值的代码对及聚类结果
+// 同一用户的提交之间不做比对
+func AnalyzePlagiarism(subs []models.Submission, threshold float64) ([]models.PlagiarismPair, []models.PlagiarismCluster) {
+	type groupKey struct {
+		questionID int
+		language   string
+	}
+	groups := make(map[groupKey][]models.Submission)
+	var keys []groupKey
+	for _, s := range subs {
+		k := groupKey{s.QuestionID, s.Language}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], s)
+	}
+
+	var pairs []models.PlagiarismPair
+	var clusters []models.PlagiarismCluster
+	for _, key := range keys {
+		group := groups[key]
+		docs := make([]*plagiarism.Document, len(group))
+		for i, s := range group {
+			docs[i] = plagiarism.NewDocument(s.ID, s.Code, s.Language, plagiarism.DefaultK, plagiarism.DefaultWindow)
+		}
+
+		var edges []plagiarism.Edge
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				if group[i].UserID == group[j].UserID {
+					continue
+				}
+				m := plagiarism.Compare(docs[i], docs[j])
+				if m.Similarity < threshold {
+					continue
+				}
+				edges = append(edges, plagiarism.Edge{A: i, B: j, Similarity: m.Similarity})
+
+				regions := make([]models.MatchedRegion, 0, len(m.Regions))
+				for _, r := range m.Regions {
+					regions = append(regions, models.MatchedRegion(r))
+				}
+				pairs = append(pairs, models.PlagiarismPair{
+					QuestionID:  key.questionID,
+					Language:    key.language,
+					SubmissionA: group[i].ID,
+					UserA:       group[i].UserID,
+					SubmissionB: group[j].ID,
+					UserB:       group[j].UserID,
+					Similarity:  m.Similarity,
+					CoverageA:   m.CoverageA,
+					CoverageB:   m.CoverageB,
+					Regions:     regions,
+				})
+			}
+		}
+
+		for _, members := range plagiarism.Cluster(len(group), edges, threshold) {
+			c := models.PlagiarismCluster{QuestionID: key.questionID, Language: key.language}
+			inCluster := make(map[int]bool, len(members))
+			for _, idx := range members {
+				inCluster[idx] = true
+				c.Submissions = append(c.Submissions, group[idx].ID)
+				c.Users = append(c.Users, group[idx].UserID)
+			}
+			for _, e := range edges {
+				if inCluster[e.A] && e.Similarity > c.MaxSimilarity {
+					c.MaxSimilarity = e.Similarity
+				}
+			}
+			clusters = append(clusters, c)
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Similarity > pairs[j].Similarity })
+	if len(pairs) > maxReportPairs {
+		pairs = pairs[:maxReportPairs]
+	}
+	return pairs, clusters
+}
+
+// DecodePlagiarismExcluded 解析报告中未参与比对的提交 ID
+func DecodePlagiarismExcluded(report *models.PlagiarismReport) []string {
+	excluded := []string{}
+	if report.Excluded != "" {
+		if err := json.Unmarshal([]byte(report.Excluded), &excluded); err != nil {
+			log.Printf("解析查重排除提交失败 report=%d err=%v", report.ID, err)
+		}
+	}
+	return excluded
+}
+
+// DecodePlagiarismReport 解析报告中保存的代码对与聚类
+func DecodePlagiarismReport(report *models.PlagiarismReport) ([]models.PlagiarismPair, []models.PlagiarismCluster) {
+	pairs := []models.PlagiarismPair{}
+	clusters := []models.PlagiarismCluster{}
+	if report.Pairs != "" {
+		if err := json.Unmarshal([]byte(report.Pairs), &pairs); err != nil {
+			log.Printf("解析查重代码对失败 report=%d err=%v", report.ID, err)
+		}
+	}
+	if report.Clusters != "" {
+		if err := json.Unmarshal([]byte(report.Clusters), &clusters); err != nil {
+			log.Printf("解析查重聚类失败 report=%d err=%v", report.ID, err)
+		}
+	}
+	return pairs, clusters
+}
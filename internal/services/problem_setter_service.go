@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dachuang/internal/models"
+	"dachuang/internal/oss"
+
+	"gorm.io/gorm"
+)
+
+// ErrTestCaseObject 测试用例引用的 OSS 对象不存在或不可访问
+var ErrTestCaseObject = errors.New("测试用例文件不可访问")
+
+// OSSTestCaseSpec OSS 测试用例落库参数
+type OSSTestCaseSpec struct {
+	QuestionID  int
+	InputKey    string
+	OutputKey   string
+	IsHidden    bool
+	IsSample    bool
+	SampleOrder int
+	KeepFlags   bool // 已存在时保留原有的隐藏/样例设置（自动生成输出时使用）
+}
+
+// CommitOSSTestCase 将 OSS 中的输入/输出文件登记为测试用例，同一题目下按 input_key 去重更新
+func CommitOSSTestCase(ctx context.Context, db *gorm.DB, ossClient *oss.OSS, bucket string, spec OSSTestCaseSpec) (*models.TestCase, error) {
+	inInfo, err := ossClient.StatObject(ctx, bucket, spec.InputKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: input_key %s: %v", ErrTestCaseObject, spec.InputKey, err)
+	}
+	outInfo, err := ossClient.StatObject(ctx, bucket, spec.OutputKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: output_key %s: %v", ErrTestCaseObject, spec.OutputKey, err)
+	}
+
+	var testCase models.TestCase
+	err = db.Where("question_id = ? AND input_key = ?", spec.QuestionID, spec.InputKey).First(&testCase).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		testCase = models.TestCase{QuestionID: spec.QuestionID, InputKey: spec.InputKey}
+	case err != nil:
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+
+	if testCase.ID == 0 || !spec.KeepFlags {
+		testCase.IsHidden = spec.IsHidden
+		testCase.IsSample = spec.IsSample
+		testCase.SampleOrder = spec.SampleOrder
+	}
+	testCase.OutputKey = spec.OutputKey
+	testCase.InputSize = inInfo.Size
+	testCase.OutputSize = outInfo.Size
+	testCase.Input = ""
+	testCase.ExpectedOutput = ""
+	testCase.ValidationStatus = ""
+	testCase.ValidationMessage = ""
+
+	if err := db.Save(&testCase).Error; err != nil {
+		return nil, fmt.Errorf("保存测试用例失败: %w", err)
+	}
+	if err := models.TestDataChanged(spec.QuestionID); err != nil {
+		return nil, fmt.Errorf("重置校验状态失败: %w", err)
+	}
+	return &testCase, nil
+}
+
+// ReferenceCheck 单个参考解的校验结果
+type ReferenceCheck struct {
+	SolutionID uint   `json:"solution_id"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Expected   string `json:"expected"`
+	Verdict    string `json:"verdict"`
+	Passed     bool   `json:"passed"`
+	Message    string `json:"message,omitempty"`
+}
+
+// ValidationReport 生成输出与参考解校验的结果
+type ValidationReport struct {
+	Generated int              `json:"generated"` // 生成的输出文件数
+	Checks    []ReferenceCheck `json:"checks"`
+	Passed    bool             `json:"passed"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// ProblemSetterService 出题辅助：用参考解生成标准输出并校验
+type ProblemSetterService struct {
+	DB        *gorm.DB
+	Judge     *JudgeService
+	OSSClient *oss.OSS
+	OSSBucket string
+}
+
+// NewProblemSetterService 创建出题辅助服务
+func NewProblemSetterService(db *gorm.DB, judge *JudgeService, ossClient *oss.OSS, bucket string) *ProblemSetterService {
+	return &ProblemSetterService{DB: db, Judge: judge, OSSClient: ossClient, OSSBucket: bucket}
+}
+
+// generationInput 待生成输出的一个输入
+type generationInput struct {
+	testCase  *models.TestCase // 已登记的测试用例，仅存在于 OSS 时为 nil
+	inputKey  string
+	outputKey string
+}
+
+// GenerateAndValidate 用主解跑遍所有输入生成 .out，再校验其他参考解是否得到预期结论
+// 校验结果写回题目的 validation_status，发布前必须为 passed
+func (ps *ProblemSetterService) GenerateAndValidate(ctx context.Context, question *models.Question) (*ValidationReport, error) {
+	var solutions []models.ReferenceSolution
+	if err := ps.DB.Where("question_id = ?", question.Id).Order("id ASC").Find(&solutions).Error; err != nil {
+		return nil, fmt.Errorf("查询参考解失败: %w", err)
+	}
+
+	var main *models.ReferenceSolution
+	for i := range solutions {
+		if solutions[i].IsMain {
+			main = &solutions[i]
+			break
+		}
+	}
+	if main == nil {
+		return nil, fmt.Errorf("题目没有设置主参考解")
+	}
+
+	report := &ValidationReport{}
+	err := ps.generateOutputs(ctx, question, main, report)
+	if err == nil {
+		err = ps.validateSolutions(question, solutions, main, report)
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	report.Passed = err == nil
+	for _, check := range report.Checks {
+		if !check.Passed {
+			report.Passed = false
+		}
+	}
+
+	status := models.ValidationFailed
+	if report.Passed {
+		status = models.ValidationPassed
+	}
+	msg, _ := json.Marshal(report)
+	if err := ps.DB.Model(&models.Question{}).Where("id = ?", question.Id).
+		Updates(map[string]interface{}{"validation_status": status, "validation_message": string(msg)}).Error; err != nil {
+		return nil, fmt.Errorf("保存校验结果失败: %w", err)
+	}
+	question.ValidationStatus = status
+	question.ValidationMessage = string(msg)
+	return report, nil
+}
+
+// collectInputs 收集题目的全部输入：已登记的测试用例，以及 problems/<编号>/ 下尚未登记的 N.in 文件
+func (ps *ProblemSetterService) collectInputs(ctx context.Context, question *models.Question) ([]generationInput, error) {
+	var testCases []models.TestCase
+	if err := ps.DB.Where("question_id = ?", question.Id).Order("id ASC").Find(&testCases).Error; err != nil {
+		return nil, fmt.Errorf("查询测试用例失败: %w", err)
+	}
+
+	var inputs []generationInput
+	known := make(map[string]bool)
+	for i := range testCases {
+		tc := &testCases[i]
+		outKey := tc.OutputKey
+		if tc.InputKey != "" {
+			known[tc.InputKey] = true
+			if outKey == "" {
+				outKey = strings.TrimSuffix(tc.InputKey, ".in") + ".out"
+			}
+		}
+		inputs = append(inputs, generationInput{testCase: tc, inputKey: tc.InputKey, outputKey: outKey})
+	}
+
+	if ps.OSSClient == nil || ps.OSSBucket == "" {
+		return inputs, nil
+	}
+	prefix := fmt.Sprintf("problems/%d/", question.QuestionNumber)
+	objects, err := ps.OSSClient.ListObjects(ctx, ps.OSSBucket, prefix, true)
+	if err != nil {
+		return nil, fmt.Errorf("列出输入文件失败: %w", err)
+	}
+	var extra []string
+	for _, key := range objects {
+		name := path.Base(key)
+		if !strings.HasSuffix(name, ".in") || known[key] {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(name, ".in")); err != nil {
+			continue
+		}
+		extra = append(extra, key)
+	}
+	sort.Slice(extra, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimSuffix(path.Base(extra[i]), ".in"))
+		b, _ := strconv.Atoi(strings.TrimSuffix(path.Base(extra[j]), ".in"))
+		return a < b
+	})
+	for _, key := range extra {
+		inputs = append(inputs, generationInput{inputKey: key, outputKey: strings.TrimSuffix(key, ".in") + ".out"})
+	}
+	return inputs, nil
+}
+
+// generateOutputs 运行主解并写回标准输出
+func (ps *ProblemSetterService) generateOutputs(ctx context.Context, question *models.Question, main *models.ReferenceSolution, report *ValidationReport) error {
+	items, err := ps.collectInputs(ctx, question)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("题目没有任何输入数据")
+	}
+
+	inputs := make([]string, len(items))
+	for i, item := range items {
+		if item.testCase != nil {
+			inputs[i], _, err = LoadTestCaseIO(ctx, ps.OSSClient, ps.OSSBucket, *item.testCase)
+		} else {
+			var b []byte
+			b, err = ps.OSSClient.GetObjectBytes(ctx, ps.OSSBucket, item.inputKey)
+			inputs[i] = string(b)
+		}
+		if err != nil {
+			return fmt.Errorf("读取输入失败: %w", err)
+		}
+	}
+
+	// 生成阶段没有期望输出可参考，统一使用配置的最大输出上限
+	limits := make([]int64, len(inputs))
+	for i := range limits {
+		limits[i] = ps.Judge.MaxOutputLimit()
+	}
+	task := JudgeTask{
+		Code:         main.Code,
+		Language:     ps.language(main),
+		Inputs:       inputs,
+		OutputLimits: limits,
+		InputFile:    question.InputFile,
+		OutputFile:   question.OutputFile,
+	}
+	if err := ps.Judge.ApplyHarness(question, &task); err != nil {
+		return err
+	}
+	results, err := ps.Judge.Run(task)
+	if err != nil {
+		return fmt.Errorf("运行主参考解失败: %w", err)
+	}
+	if len(results) != len(items) {
+		return fmt.Errorf("主参考解运行结果数量不匹配: got=%d want=%d", len(results), len(items))
+	}
+
+	for i, r := range results {
+		if r.Verdict != "" && r.Verdict != models.VerdictAccepted {
+			return fmt.Errorf("主参考解在第 %d 个输入上运行失败: %s %s", i+1, r.Verdict, r.Stderr)
+		}
+		output := asFileContent(r.ActualOutput)
+		item := items[i]
+
+		if item.inputKey == "" {
+			// 内联测试用例直接更新数据库中的期望输出
+			if err := ps.DB.Model(item.testCase).Update("expected_output", output).Error; err != nil {
+				return fmt.Errorf("保存期望输出失败: %w", err)
+			}
+			if err := models.TestDataChanged(question.Id); err != nil {
+				return fmt.Errorf("重置校验状态失败: %w", err)
+			}
+		} else {
+			if _, err := ps.OSSClient.UploadFile(ctx, ps.OSSBucket, item.outputKey, strings.NewReader(output), int64(len(output)), "text/plain"); err != nil {
+				return fmt.Errorf("上传输出文件失败(key=%s): %w", item.outputKey, err)
+			}
+			spec := OSSTestCaseSpec{QuestionID: question.Id, InputKey: item.inputKey, OutputKey: item.outputKey, KeepFlags: true}
+			if _, err := CommitOSSTestCase(ctx, ps.DB, ps.OSSClient, ps.OSSBucket, spec); err != nil {
+				return err
+			}
+		}
+		report.Generated++
+	}
+	return nil
+}
+
+// RunReference 在单个输入上运行参考解（按题目的 IO 方式），返回其输出
+func (ps *ProblemSetterService) RunReference(question *models.Question, sol *models.ReferenceSolution, input string) (string, error) {
+	task := JudgeTask{
+		Code:         sol.Code,
+		Language:     ps.language(sol),
+		Inputs:       []string{input},
+		OutputLimits: []int64{ps.Judge.MaxOutputLimit()},
+		InputFile:    question.InputFile,
+		OutputFile:   question.OutputFile,
+	}
+	if err := ps.Judge.ApplyHarness(question, &task); err != nil {
+		return "", err
+	}
+	results, err := ps.Judge.Run(task)
+	if err != nil {
+		return "", fmt.Errorf("运行参考解失败: %w", err)
+	}
+	if len(results) != 1 {
+		return "", fmt.Errorf("参考解运行结果数量不匹配: got=%d", len(results))
+	}
+	if r := results[0]; r.Verdict != "" && r.Verdict != models.VerdictAccepted {
+		return "", fmt.Errorf("参考解运行失败: %s %s", r.Verdict, r.Stderr)
+	}
+	return asFileContent(results[0].ActualOutput), nil
+}
+
+// validateSolutions 用完整评测时的测试点评测非主参考解，检查是否得到预期结论
+func (ps *ProblemSetterService) validateSolutions(question *models.Question, solutions []models.ReferenceSolution, main *models.ReferenceSolution, report *ValidationReport) error {
+	testCases, err := ps.Judge.getTestCases(question.Id, models.JudgeModeFull)
+	if err != nil {
+		return fmt.Errorf("查询测试用例失败: %w", err)
+	}
+
+	for i := range solutions {
+		sol := &solutions[i]
+		check := ReferenceCheck{SolutionID: sol.ID, Name: sol.Name, Kind: sol.Kind, Expected: sol.ExpectedVerdict()}
+
+		if sol.ID == main.ID {
+			check.Verdict, check.Passed = models.VerdictAccepted, true
+		} else {
+			results, err := ps.Judge.JudgeTestCases(question, sol.Code, ps.language(sol), testCases)
+			if err != nil {
+				check.Message = err.Error()
+			} else {
+				check.Verdict = models.OverallVerdict(results)
+				check.Passed = matchesExpected(results, check.Expected)
+			}
+		}
+
+		ps.DB.Model(sol).Updates(map[string]interface{}{"last_verdict": check.Verdict, "last_passed": check.Passed})
+		report.Checks = append(report.Checks, check)
+	}
+	return nil
+}
+
+// matchesExpected 正确解需全部通过；TLE/WA 解只需在任一测试点出现对应结论
+func matchesExpected(results []models.TestCaseResult, expected string) bool {
+	if expected == models.VerdictAccepted {
+		return models.OverallVerdict(results) == models.VerdictAccepted
+	}
+	for _, r := range results {
+		if r.Verdict == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// language 参考解的语言，未填写时自动识别
+func (ps *ProblemSetterService) language(sol *models.ReferenceSolution) string {
+	if sol.Language != "" {
+		return sol.Language
+	}
+	return ps.Judge.DetectLanguage(sol.Code)
+}
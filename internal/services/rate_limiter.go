@@ -0,0 +1,223 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"dachuang/internal/config"
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitStore 令牌桶状态存储
+type RateLimitStore interface {
+	// Take 尝试从 key 对应的桶中取出一个令牌，失败时返回需要等待的时间
+	Take(key string, bucket config.TokenBucketConfig, now time.Time) (bool, time.Duration, error)
+	// Refund 归还一个已取出的令牌（不超过容量）
+	Refund(key string, bucket config.TokenBucketConfig) error
+}
+
+// takeToken 按经过的时间补充令牌后尝试取出一个，返回新的令牌数
+func takeToken(tokens float64, refilledAt time.Time, bucket config.TokenBucketConfig, now time.Time) (float64, bool, time.Duration) {
+	interval := bucket.RefillSeconds
+	if interval <= 0 {
+		interval = 1
+	}
+	if elapsed := now.Sub(refilledAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(bucket.Capacity), tokens+elapsed/interval)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	wait := time.Duration((1 - tokens) * interval * float64(time.Second))
+	return tokens, false, wait
+}
+
+// memoryBucket 内存中的令牌桶状态
+type memoryBucket struct {
+	tokens     float64
+	refilledAt time.Time
+}
+
+// memoryPruneThreshold 内存桶数量超过该值时清理已回满的桶
+const memoryPruneThreshold = 10000
+
+// MemoryRateLimitStore 进程内存储，仅适用于单实例部署
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryRateLimitStore 创建内存存储
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, bucket config.TokenBucketConfig, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buckets) > memoryPruneThreshold {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(bucket.Capacity), refilledAt: now}
+		s.buckets[key] = b
+	}
+	tokens, allowed, wait := takeToken(b.tokens, b.refilledAt, bucket, now)
+	b.tokens, b.refilledAt = tokens, now
+	return allowed, wait, nil
+}
+
+func (s *MemoryRateLimitStore) Refund(key string, bucket config.TokenBucketConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[key]; ok {
+		b.tokens = math.Min(float64(bucket.Capacity), b.tokens+1)
+	}
+	return nil
+}
+
+// prune 删除长时间未使用的桶（已回满的桶与新建桶等价）
+func (s *MemoryRateLimitStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.refilledAt) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// DBRateLimitStore 数据库存储，通过行锁保证多实例间的一致性
+type DBRateLimitStore struct {
+	db *gorm.DB
+}
+
+// NewDBRateLimitStore 创建数据库存储
+func NewDBRateLimitStore(db *gorm.DB) *DBRateLimitStore {
+	return &DBRateLimitStore{db: db}
+}
+
+func (s *DBRateLimitStore) Take(key string, bucket config.TokenBucketConfig, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		row := models.RateLimitBucket{BucketKey: key, Tokens: float64(bucket.Capacity), RefilledAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+
+		// SQLite 不支持 FOR UPDATE，写事务本身已串行
+		q := tx
+		if tx.Dialector.Name() != "sqlite" {
+			q = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := q.Where("bucket_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, allowed, wait = takeToken(row.Tokens, row.RefilledAt, bucket, now)
+		return tx.Model(&models.RateLimitBucket{}).Where("bucket_key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "refilled_at": now}).Error
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed, wait, nil
+}
+
+func (s *DBRateLimitStore) Refund(key string, bucket config.TokenBucketConfig) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		q := tx
+		if tx.Dialector.Name() != "sqlite" {
+			q = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var row models.RateLimitBucket
+		if err := q.Where("bucket_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+		tokens := math.Min(float64(bucket.Capacity), row.Tokens+1)
+		return tx.Model(&models.RateLimitBucket{}).Where("bucket_key = ?", key).Update("tokens", tokens).Error
+	})
+}
+
+// RateLimitRequest 一次提交的限流维度
+type RateLimitRequest struct {
+	UserID     string
+	IP         string
+	QuestionID int
+	Contest    bool // 比赛提交使用独立的限制
+}
+
+// RateLimiter 提交频率限制
+type RateLimiter struct {
+	Config config.RateLimitConfig
+	Store  RateLimitStore
+}
+
+// NewRateLimiter 根据配置创建限流器
+func NewRateLimiter(cfg config.RateLimitConfig, db *gorm.DB) *RateLimiter {
+	var store RateLimitStore
+	if strings.EqualFold(strings.TrimSpace(cfg.Store), "database") && db != nil {
+		store = NewDBRateLimitStore(db)
+	} else {
+		store = NewMemoryRateLimitStore()
+	}
+	return &RateLimiter{Config: cfg, Store: store}
+}
+
+// AllowSubmission 依次检查用户、IP、题目三个维度，任一维度耗尽即拒绝并返回建议等待时间，
+// 被拒绝的提交归还已从其他维度取出的令牌；存储异常时放行，避免限流组件故障导致无法提交
+func (rl *RateLimiter) AllowSubmission(req RateLimitRequest) (bool, time.Duration) {
+	if rl == nil || !rl.Config.Enabled {
+		return true, 0
+	}
+
+	scope := "practice"
+	rules := rl.Config.Practice
+	if req.Contest && !rl.Config.Contest.IsZero() {
+		scope = "contest"
+		rules = rl.Config.Contest
+	}
+
+	checks := []struct {
+		key    string
+		bucket config.TokenBucketConfig
+	}{
+		{fmt.Sprintf("submit:%s:user:%s", scope, req.UserID), rules.User},
+		{fmt.Sprintf("submit:%s:ip:%s", scope, req.IP), rules.IP},
+		{fmt.Sprintf("submit:%s:problem:%s:%d", scope, req.UserID, req.QuestionID), rules.Problem},
+	}
+
+	now := time.Now()
+	var taken []int
+	for i, check := range checks {
+		if check.bucket.Capacity <= 0 {
+			continue
+		}
+		allowed, wait, err := rl.Store.Take(check.key, check.bucket, now)
+		if err != nil {
+			log.Printf("限流存储异常，已放行 key=%s err=%v", check.key, err)
+			continue
+		}
+		if !allowed {
+			for _, j := range taken {
+				if err := rl.Store.Refund(checks[j].key, checks[j].bucket); err != nil {
+					log.Printf("归还限流令牌失败 key=%s err=%v", checks[j].key, err)
+				}
+			}
+			return false, wait
+		}
+		taken = append(taken, i)
+	}
+	return true, 0
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrContestNotRated 比赛不是计分比赛
+	ErrContestNotRated = errors.New("比赛不是计分比赛")
+	// ErrRatingApplied 比赛已经更新过 rating
+	ErrRatingApplied = errors.New("比赛已更新过 rating")
+	// ErrRatingNotApplied 比赛尚未更新 rating
+	ErrRatingNotApplied = errors.New("比赛尚未更新 rating")
+	// ErrStandingsNotFinal 比赛未结束或仍有提交在评测中
+	ErrStandingsNotFinal = errors.New("比赛尚未产生最终榜单")
+)
+
+// RatingParticipant 参与 rating 计算的选手
+type RatingParticipant struct {
+	UserID string
+	Rank   int // 榜单名次，并列时相同
+	Rating int // 赛前 rating
+}
+
+// eloWinProbability 评分为 a 的选手胜过评分为 b 的选手的概率
+func eloWinProbability(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// expectedRank 评分为 rating 的选手在其余选手中的期望名次（从 1 开始），skip 为选手自身下标
+func expectedRank(ps []RatingParticipant, rating float64, skip int) float64 {
+	seed := 1.0
+	for j, p := range ps {
+		if j != skip {
+			seed += eloWinProbability(float64(p.Rating), rating)
+		}
+	}
+	return seed
+}
+
+// CalculateRatingDeltas 按 Codeforces 的方式计算 rating 变化：
+// 以期望名次与实际名次的几何平均为目标名次，反推达到该名次所需的评分，变化量为差值的一半；
+// 随后整体修正使变化量之和略小于零，并限制高分段选手的总增量
+func CalculateRatingDeltas(ps []RatingParticipant) []int {
+	n := len(ps)
+	deltas := make([]int, n)
+	if n < 2 {
+		return deltas
+	}
+	for i, p := range ps {
+		target := math.Sqrt(expectedRank(ps, float64(p.Rating), i) * float64(p.Rank))
+		lo, hi := 1, 8000
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			if expectedRank(ps, float64(mid), i) < target {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		deltas[i] = (lo - p.Rating) / 2
+	}
+
+	sum := 0
+	for _, d := range deltas {
+		sum += d
+	}
+	inc := -sum/n - 1
+	for i := range deltas {
+		deltas[i] += inc
+	}
+
+	// 赛前评分最高的 4√n 名选手的变化量之和不超过 0
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ps[order[a]].Rating > ps[order[b]].Rating })
+	top := int(math.Min(float64(n), 4*math.Round(math.Sqrt(float64(n)))))
+	sum = 0
+	for _, i := range order[:top] {
+		sum += deltas[i]
+	}
+	inc = -sum / top
+	if inc < -10 {
+		inc = -10
+	}
+	if inc > 0 {
+		inc = 0
+	}
+	for i := range deltas {
+		deltas[i] += inc
+	}
+	return deltas
+}
+
+// RatingService 根据计分比赛的最终榜单维护用户 rating
+type RatingService struct {
+	DB         *gorm.DB
+	Scoreboard *ScoreboardService
+
+	mu sync.Mutex // 串行化 rating 的更新、回滚与重算
+}
+
+// NewRatingService 创建 rating 服务
+func NewRatingService(db *gorm.DB, scoreboard *ScoreboardService) *RatingService {
+	return &RatingService{DB: db, Scoreboard: scoreboard}
+}
+
+// participants 比赛的最终名次：只统计有有效提交的报名选手，虚拟参赛不计入
+func (s *RatingService) participants(contest *models.Contest) ([]RatingParticipant, error) {
+	if contest.State(time.Now()) != models.ContestEnded || contest.ResultsHidden() {
+		return nil, ErrStandingsNotFinal
+	}
+	board, err := s.Scoreboard.Board(contest.ID)
+	if err != nil {
+		return nil, err
+	}
+	var ps []RatingParticipant
+	for _, row := range board.Standings(false).Rows {
+		participated := false
+		for _, c := range row.Cells {
+			if c.Pending > 0 {
+				return nil, ErrStandingsNotFinal
+			}
+			if c.Solved || c.Attempts > 0 || c.Score > 0 {
+				participated = true
+			}
+		}
+		if participated {
+			ps = append(ps, RatingParticipant{UserID: row.UserID, Rank: row.Rank})
+		}
+	}
+	return ps, nil
+}
+
+// applyContest 在 tx 中写入一场比赛的 rating 变化；current 为用户当前 rating，
+// 为 nil 时从 user_ratings 读取。返回写入的变化
+func (s *RatingService) applyContest(tx *gorm.DB, contest *models.Contest, current map[string]*models.UserRating) ([]models.RatingChange, error) {
+	ps, err := s.participants(contest)
+	if err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		return []models.RatingChange{}, nil
+	}
+
+	ratings := make(map[string]*models.UserRating, len(ps))
+	for _, p := range ps {
+		if r, ok := current[p.UserID]; ok {
+			ratings[p.UserID] = r
+		}
+	}
+	if current == nil {
+		ids := make([]string, 0, len(ps))
+		for _, p := range ps {
+			ids = append(ids, p.UserID)
+		}
+		var stored []models.UserRating
+		if err := tx.Where("user_id IN ?", ids).Find(&stored).Error; err != nil {
+			return nil, err
+		}
+		for i := range stored {
+			ratings[stored[i].UserID] = &stored[i]
+		}
+	}
+	for i, p := range ps {
+		r, ok := ratings[p.UserID]
+		if !ok {
+			r = &models.UserRating{UserID: p.UserID, Rating: models.InitialRating, MaxRating: models.InitialRating}
+			ratings[p.UserID] = r
+			if current != nil {
+				current[p.UserID] = r
+			}
+		}
+		ps[i].Rating = r.Rating
+	}
+
+	deltas := CalculateRatingDeltas(ps)
+	changes := make([]models.RatingChange, 0, len(ps))
+	for i, p := range ps {
+		r := ratings[p.UserID]
+		change := models.RatingChange{
+			ContestID: contest.ID,
+			UserID:    p.UserID,
+			Rank:      p.Rank,
+			OldRating: r.Rating,
+			NewRating: r.Rating + deltas[i],
+			Delta:     deltas[i],
+		}
+		changes = append(changes, change)
+		r.Rating = change.NewRating
+		if r.Contests == 0 || r.Rating > r.MaxRating {
+			r.MaxRating = r.Rating
+		}
+		r.Contests++
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(r).Error; err != nil {
+			return nil, fmt.Errorf("保存用户 rating 失败: %w", err)
+		}
+	}
+	if err := tx.Create(&changes).Error; err != nil {
+		return nil, fmt.Errorf("保存 rating 变化失败: %w", err)
+	}
+	return changes, nil
+}
+
+// Apply 按比赛的最终榜单更新 rating；比赛早于已计分的比赛结束时按时间顺序全部重算
+func (s *RatingService) Apply(contestID uint) ([]models.RatingChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var contest models.Contest
+	if err := s.DB.Where("id = ?", contestID).First(&contest).Error; err != nil {
+		return nil, err
+	}
+	switch {
+	case !contest.Rated:
+		return nil, ErrContestNotRated
+	case contest.RatedAt != nil:
+		return nil, ErrRatingApplied
+	}
+	if _, err := s.participants(&contest); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var later int64
+	if err := s.DB.Model(&models.Contest{}).Where("rated_at IS NOT NULL AND end_time > ?", contest.EndTime).Count(&later).Error; err != nil {
+		return nil, err
+	}
+	if later > 0 {
+		if err := s.DB.Model(&contest).Update("rated_at", now).Error; err != nil {
+			return nil, err
+		}
+		if _, err := s.recompute(); err != nil {
+			s.DB.Model(&contest).Update("rated_at", nil)
+			return nil, err
+		}
+		var changes []models.RatingChange
+		err := s.DB.Where("contest_id = ?", contestID).Order("`rank` ASC").Find(&changes).Error
+		return changes, err
+	}
+
+	var changes []models.RatingChange
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if changes, err = s.applyContest(tx, &contest, nil); err != nil {
+			return err
+		}
+		return tx.Model(&contest).Update("rated_at", now).Error
+	})
+	return changes, err
+}
+
+// Rollback 撤销一场比赛的 rating 变化：标记为未更新后按时间顺序重算全部 rating
+func (s *RatingService) Rollback(contestID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var contest models.Contest
+	if err := s.DB.Where("id = ?", contestID).First(&contest).Error; err != nil {
+		return err
+	}
+	ratedAt := contest.RatedAt
+	if ratedAt == nil {
+		return ErrRatingNotApplied
+	}
+	if err := s.DB.Model(&contest).Update("rated_at", nil).Error; err != nil {
+		return err
+	}
+	if _, err := s.recompute(); err != nil {
+		s.DB.Model(&contest).Update("rated_at", ratedAt)
+		return err
+	}
+	return nil
+}
+
+// Recompute 清空全部 rating，按结束时间顺序对已更新过 rating 的比赛重新计算，返回比赛场数
+func (s *RatingService) Recompute() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recompute()
+}
+
+// recompute 调用方需持有 s.mu
+func (s *RatingService) recompute() (int, error) {
+	var contests []models.Contest
+	if err := s.DB.Where("rated = ? AND rated_at IS NOT NULL", true).Order("end_time ASC, id ASC").Find(&contests).Error; err != nil {
+		return 0, err
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.RatingChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&models.UserRating{}).Error; err != nil {
+			return err
+		}
+		current := make(map[string]*models.UserRating)
+		for i := range contests {
+			if _, err := s.applyContest(tx, &contests[i], current); err != nil {
+				return fmt.Errorf("比赛 %d: %w", contests[i].ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(contests), nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"dachuang/internal/models"
+)
+
+// resultHashVersion 摘要格式版本，参与摘要计算的内容变化时递增以废弃旧缓存
+const resultHashVersion = "v1"
+
+// normalizeCode 规范化源码：统一换行符，去掉行尾空白与末尾空行
+func normalizeCode(code string) string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	lines := strings.Split(code, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// ResultHash 计算评测结果的缓存键：规范化后的代码（或全部源文件）、语言、评测模式、
+// 题目测试数据版本与 OSS 测试数据摘要、题目限制与 IO 方式、题型与函数签名，以及评测后端的资源限制
+func (js *JudgeService) ResultHash(question *models.Question, submission *models.Submission, files []SourceFile, dataDigest string) string {
+	h := sha256.New()
+	field := func(v interface{}) {
+		fmt.Fprintf(h, "%v\x00", v)
+	}
+	field(resultHashVersion)
+	field(submission.Language)
+	field(submission.JudgeMode)
+	field(question.Id)
+	field(question.TestDataVersion)
+	field(dataDigest)
+	field(question.TimeLimit)
+	field(question.MemoryLimit)
+	field(question.OutputLimit)
+	field(question.InputFile)
+	field(question.OutputFile)
+	field(question.ProblemType)
+	field(question.Signature)
+	field(js.Config.Mode)
+	field(js.Config.GoJudge.MaxTime)
+	field(js.Config.GoJudge.MaxMemory)
+	field(js.Config.Local.MaxTime)
+	field(js.Config.Local.MaxMemory)
+	field(js.Config.Local.MaxOutputSize)
+	if files != nil {
+		field(js.Config.MultiFile.Build[submission.Language])
+		for _, f := range files {
+			field(f.Path)
+			field(len(f.Content))
+			io.WriteString(h, f.Content)
+		}
+	} else {
+		io.WriteString(h, normalizeCode(submission.Code))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// testDataDigest 测试用例引用的 OSS 对象的 ETag 摘要。OSS 中的测试文件可能经 /oss/upload 或预签名 URL
+// 直接覆盖而不更新 TestDataVersion（题目没有测试用例记录时直接按目录读取），计入缓存键后内容变化即不再复用旧结果
+func (js *JudgeService) testDataDigest(ctx context.Context, question *models.Question, testCases []models.TestCase) (string, error) {
+	if js.OSSClient == nil || js.OSSBucket == "" {
+		return "", nil
+	}
+
+	var keys []string
+	for _, tc := range testCases {
+		if tc.InputKey != "" {
+			keys = append(keys, tc.InputKey)
+		}
+		if tc.OutputKey != "" {
+			keys = append(keys, tc.OutputKey)
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+
+	// 测试文件通常都在题目目录下，一次列举取得 ETag，不在目录下的再逐个查询
+	objects, err := js.OSSClient.ListObjectsInfo(ctx, js.OSSBucket, fmt.Sprintf("problems/%d/", question.QuestionNumber), true)
+	if err != nil {
+		return "", err
+	}
+	etags := make(map[string]string, len(objects))
+	for _, obj := range objects {
+		etags[obj.Key] = obj.ETag
+	}
+
+	h := sha256.New()
+	for _, key := range keys {
+		etag, ok := etags[key]
+		if !ok {
+			info, err := js.OSSClient.StatObject(ctx, js.OSSBucket, key)
+			if err != nil {
+				return "", err
+			}
+			etag = info.ETag
+		}
+		fmt.Fprintf(h, "%s=%s\x00", key, etag)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findCachedResult 查找摘要相同且已完成评测的提交；超时结论受机器负载影响，不复用
+func (js *JudgeService) findCachedResult(submission *models.Submission) (*models.Submission, bool) {
+	if !js.Config.ResultCache || submission.ResultHash == "" {
+		return nil, false
+	}
+	var cached models.Submission
+	err := js.DB.Where("result_hash = ? AND id <> ? AND status = ? AND verdict <> '' AND verdict <> ?",
+		submission.ResultHash, submission.ID, "completed", models.VerdictTimeLimitExceeded).
+		Order("created_at DESC").First(&cached).Error
+	if err != nil {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// applyCachedResult 将缓存提交的逐个测试点结果复制到当前提交
+func applyCachedResult(submission, cached *models.Submission) {
+	submission.Results = cached.Results
+	submission.Verdict = cached.Verdict
+	submission.RuntimeMs = cached.RuntimeMs
+	submission.MemoryKB = cached.MemoryKB
+	submission.CachedFrom = cached.ID
+	if cached.CachedFrom != "" {
+		submission.CachedFrom = cached.CachedFrom
+	}
+}
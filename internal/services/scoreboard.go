@@ -0,0 +1,610 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"dachuang/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// icpcPenaltyMinutes 通过前每次错误提交的罚时（分钟）
+	icpcPenaltyMinutes = 20
+	// scoreboardEventBuffer 榜单事件订阅的缓冲，缓冲溢出丢事件后已加载的榜单会全部重建
+	scoreboardEventBuffer = 4096
+)
+
+// ErrNothingToReveal 没有需要公布的封榜提交
+var ErrNothingToReveal = errors.New("没有未公布的提交")
+
+// ScoreCell 选手在一道题上的结果
+type ScoreCell struct {
+	Solved     bool `json:"solved"`
+	Attempts   int  `json:"attempts"` // icpc 为通过前的错误提交数（CE 不计），未通过时为全部错误提交数；oi/ioi 为已评测的提交数
+	Time       int  `json:"time"`     // 通过时间（距比赛开始的分钟数）
+	Pending    int  `json:"pending"`  // 评测中的提交
+	Frozen     int  `json:"frozen"`   // 封榜后尚未公布的提交
+	FirstBlood bool `json:"first_blood"`
+	Score      int  `json:"score,omitempty"` // oi/ioi 得分，满分时 Solved 为真
+
+	solvedAt time.Time
+}
+
+// ScoreRow 榜单中的一行
+type ScoreRow struct {
+	Rank     int         `json:"rank"`
+	UserID   string      `json:"user_id"`
+	Username string      `json:"username"`
+	Nickname string      `json:"nickname"`
+	Solved   int         `json:"solved"`
+	Penalty  int         `json:"penalty"`         // 罚时（分钟）
+	Score    int         `json:"score,omitempty"` // oi/ioi 总分
+	Virtual  bool        `json:"virtual,omitempty"`
+	Cells    []ScoreCell `json:"cells"` // 与 Problems 顺序一致
+}
+
+// ScoreProblem 榜单中的题目及统计
+type ScoreProblem struct {
+	Label          string `json:"label"`
+	QuestionID     int    `json:"question_id"`
+	QuestionNumber int    `json:"question_number"`
+	Title          string `json:"title"`
+	Solved         int    `json:"solved"`
+	Tried          int    `json:"tried"`
+	FirstBlood     string `json:"first_blood,omitempty"` // 一血选手
+	FullScore      int    `json:"full_score,omitempty"`  // oi/ioi 题目满分
+	Subtasks       string `json:"-"`
+}
+
+// Standings 榜单快照
+type Standings struct {
+	ContestID uint           `json:"contest_id"`
+	Frozen    bool           `json:"frozen"` // 是否隐藏了封榜后的提交
+	FreezeAt  *time.Time     `json:"freeze_at,omitempty"`
+	Problems  []ScoreProblem `json:"problems"`
+	Rows      []ScoreRow     `json:"rows"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// scoreSub 榜单中的一次提交
+type scoreSub struct {
+	ID       string
+	At       time.Time
+	Status   string
+	Verdict  string
+	Score    int
+	Subtasks []models.SubtaskScore
+}
+
+// scoreUser 参赛选手及其在各题上的提交（按提交时间升序）
+type scoreUser struct {
+	UserID   string
+	Username string
+	Nickname string
+	virtual  bool
+	subs     [][]scoreSub
+}
+
+// revealKey 滚榜格子
+type revealKey struct {
+	userID     string
+	questionID int
+}
+
+// Scoreboard 单场比赛的榜单状态，提交变化时只更新对应格子，快照按视图缓存
+type Scoreboard struct {
+	mu       sync.Mutex
+	contest  models.Contest
+	problems []ScoreProblem
+	index    map[int]int // 题目 ID -> problems 下标
+	users    map[string]*scoreUser
+	revealed map[revealKey]bool
+	cache    map[bool]*Standings // 按是否封榜视图缓存
+}
+
+// apply 写入或更新一次提交；不在比赛时间内、非报名选手、非比赛题目与虚拟参赛的提交忽略
+func (b *Scoreboard) apply(sub models.Submission) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user, ok := b.users[sub.UserID]
+	if !ok || sub.JudgeMode != models.JudgeModeFull || sub.Virtual {
+		return
+	}
+	idx, ok := b.index[sub.QuestionID]
+	if !ok || sub.CreatedAt.Before(b.contest.StartTime) || !sub.CreatedAt.Before(b.contest.EndTime) {
+		return
+	}
+
+	entry := scoreSub{ID: sub.ID, At: sub.CreatedAt, Status: sub.Status, Verdict: sub.Verdict,
+		Score: sub.Score, Subtasks: ParseSubtaskScores(sub.SubtaskScores)}
+	subs := user.subs[idx]
+	for i := range subs {
+		if subs[i].ID == sub.ID {
+			subs[i] = entry
+			b.cache = make(map[bool]*Standings)
+			return
+		}
+	}
+	subs = append(subs, entry)
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].At.Before(subs[j].At) })
+	user.subs[idx] = subs
+	b.cache = make(map[bool]*Standings)
+}
+
+// 提交对榜单的影响
+const (
+	subIgnored = iota // 编译错误、无法评测或已取消
+	subPending        // 评测中或等待重试
+	subAccepted
+	subRejected
+)
+
+// classifySub 按提交状态与结论分类
+func classifySub(s scoreSub) int {
+	switch s.Status {
+	case "completed":
+		switch s.Verdict {
+		case models.VerdictAccepted:
+			return subAccepted
+		case models.VerdictCompileError, "":
+			return subIgnored
+		default:
+			return subRejected
+		}
+	case "pending", "processing", "system_error":
+		return subPending
+	default:
+		return subIgnored
+	}
+}
+
+// cell 计算一个格子；hidden 的提交只计入 Frozen，通过之后的提交不再影响结果
+func (b *Scoreboard) cell(subs []scoreSub, hidden func(scoreSub) bool) ScoreCell {
+	var c ScoreCell
+	for _, s := range subs {
+		if c.Solved {
+			break
+		}
+		if hidden(s) {
+			c.Frozen++
+			continue
+		}
+		switch classifySub(s) {
+		case subPending:
+			c.Pending++
+		case subAccepted:
+			c.Solved = true
+			c.solvedAt = s.At
+			c.Time = int(s.At.Sub(b.contest.StartTime) / time.Minute)
+		case subRejected:
+			c.Attempts++
+		}
+	}
+	return c
+}
+
+// scored 是否按得分排名（oi/ioi 赛制）
+func (b *Scoreboard) scored() bool {
+	return b.contest.Rule == models.ContestRuleOI || b.contest.Rule == models.ContestRuleIOI
+}
+
+// scoreCell 计算 oi/ioi 赛制的格子：oi 取最后一次评测完成的提交得分，ioi 每个子任务取所有提交中的最高分
+func (b *Scoreboard) scoreCell(subs []scoreSub, fullScore int) ScoreCell {
+	var c ScoreCell
+	best := make(map[int]int)
+	for _, s := range subs {
+		switch classifySub(s) {
+		case subPending:
+			c.Pending++
+			continue
+		case subIgnored:
+			if s.Verdict != models.VerdictCompileError {
+				continue
+			}
+		}
+		c.Attempts++
+		if b.contest.Rule == models.ContestRuleOI {
+			c.Score = s.Score
+			continue
+		}
+		for _, st := range s.Subtasks {
+			if st.Score > best[st.ID] {
+				best[st.ID] = st.Score
+			}
+		}
+	}
+	if b.contest.Rule == models.ContestRuleIOI {
+		for _, score := range best {
+			c.Score += score
+		}
+	}
+	c.Solved = c.Attempts > 0 && c.Score >= fullScore
+	return c
+}
+
+// standings 计算榜单；frozen 为真且比赛处于封榜时隐藏封榜后未公布的提交。调用方需持有 b.mu
+func (b *Scoreboard) standings(frozen bool) *Standings {
+	freezeAt := b.contest.FreezeTime()
+	frozen = frozen && b.contest.IsFrozen(time.Now())
+	if cached, ok := b.cache[frozen]; ok {
+		return cached
+	}
+
+	st := &Standings{ContestID: b.contest.ID, Frozen: frozen, UpdatedAt: time.Now()}
+	if !freezeAt.IsZero() {
+		st.FreezeAt = &freezeAt
+	}
+	st.Problems = make([]ScoreProblem, len(b.problems))
+	copy(st.Problems, b.problems)
+
+	scored := b.scored()
+	firstAt := make([]time.Time, len(b.problems))
+	rows := make([]ScoreRow, 0, len(b.users))
+	for _, u := range b.users {
+		row := ScoreRow{UserID: u.UserID, Username: u.Username, Nickname: u.Nickname, Virtual: u.virtual, Cells: make([]ScoreCell, len(b.problems))}
+		for i, p := range b.problems {
+			key := revealKey{u.UserID, p.QuestionID}
+			hidden := func(s scoreSub) bool {
+				return frozen && !s.At.Before(freezeAt) && !b.revealed[key]
+			}
+			if scored {
+				c := b.scoreCell(u.subs[i], p.FullScore)
+				row.Cells[i] = c
+				row.Score += c.Score
+				if c.Solved {
+					row.Solved++
+					st.Problems[i].Solved++
+				}
+				if c.Attempts > 0 || c.Pending > 0 {
+					st.Problems[i].Tried++
+				}
+				continue
+			}
+			c := b.cell(u.subs[i], hidden)
+			row.Cells[i] = c
+			if c.Solved {
+				row.Solved++
+				row.Penalty += c.Time + icpcPenaltyMinutes*c.Attempts
+				st.Problems[i].Solved++
+				if firstAt[i].IsZero() || c.solvedAt.Before(firstAt[i]) {
+					firstAt[i] = c.solvedAt
+				}
+			}
+			if c.Solved || c.Attempts > 0 || c.Pending > 0 || c.Frozen > 0 {
+				st.Problems[i].Tried++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	// 一血：该题最早的通过提交（仅 icpc）
+	for r := range rows {
+		for i := range rows[r].Cells {
+			c := &rows[r].Cells[i]
+			if !scored && c.Solved && c.solvedAt.Equal(firstAt[i]) {
+				c.FirstBlood = true
+				if st.Problems[i].FirstBlood == "" {
+					st.Problems[i].FirstBlood = rows[r].UserID
+				}
+			}
+		}
+	}
+
+	// oi/ioi 按总分排名，同分并列
+	sort.Slice(rows, func(i, j int) bool {
+		if scored && rows[i].Score != rows[j].Score {
+			return rows[i].Score > rows[j].Score
+		}
+		if !scored && rows[i].Solved != rows[j].Solved {
+			return rows[i].Solved > rows[j].Solved
+		}
+		if !scored && rows[i].Penalty != rows[j].Penalty {
+			return rows[i].Penalty < rows[j].Penalty
+		}
+		return rows[i].Username < rows[j].Username
+	})
+	for i := range rows {
+		tied := i > 0 && rows[i].Solved == rows[i-1].Solved && rows[i].Penalty == rows[i-1].Penalty
+		if scored {
+			tied = i > 0 && rows[i].Score == rows[i-1].Score
+		}
+		if tied {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+	st.Rows = rows
+	b.cache[frozen] = st
+	return st
+}
+
+// Standings 返回榜单快照，frozen 为真时返回选手看到的封榜视图
+func (b *Scoreboard) Standings(frozen bool) *Standings {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.standings(frozen)
+}
+
+// nextReveal 滚榜顺序：当前公开榜单中排名最靠后、仍有未公布提交的选手，取其题号最靠前的格子。调用方需持有 b.mu
+func (b *Scoreboard) nextReveal() (string, int, bool) {
+	st := b.standings(true)
+	if !st.Frozen {
+		return "", 0, false
+	}
+	for r := len(st.Rows) - 1; r >= 0; r-- {
+		for i, c := range st.Rows[r].Cells {
+			if c.Frozen > 0 {
+				return st.Rows[r].UserID, b.problems[i].QuestionID, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// ScoreboardService 维护各比赛的榜单：首次查询时从数据库构建，之后由评测事件增量更新
+type ScoreboardService struct {
+	DB *gorm.DB
+
+	mu     sync.Mutex
+	boards map[uint]*Scoreboard
+}
+
+// NewScoreboardService 创建榜单服务并订阅比赛提交的评测事件
+func NewScoreboardService(db *gorm.DB, events *EventHub) *ScoreboardService {
+	s := &ScoreboardService{DB: db, boards: make(map[uint]*Scoreboard)}
+	if events != nil {
+		sub := events.SubscribeBuffered(func(ev JudgeEvent) bool {
+			return ev.ContestID != 0 && (ev.Type == EventQueued || ev.Type == EventFinished || ev.Type == EventRetry)
+		}, scoreboardEventBuffer)
+		go s.consume(sub)
+	}
+	return s
+}
+
+// consume 把提交的最新状态写入已加载的榜单；订阅丢过事件时丢弃全部榜单，下次查询时从数据库重建
+func (s *ScoreboardService) consume(sub *EventSubscription) {
+	for ev := range sub.C {
+		if sub.Dropped() {
+			log.Printf("榜单事件缓冲溢出，重建全部榜单")
+			s.mu.Lock()
+			s.boards = make(map[uint]*Scoreboard)
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Lock()
+		board, ok := s.boards[ev.ContestID]
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+		var submission models.Submission
+		if err := s.DB.Select("id", "user_id", "question_id", "judge_mode", "status", "verdict", "score", "subtask_scores", "virtual", "created_at").
+			Where("id = ?", ev.SubmissionID).First(&submission).Error; err != nil {
+			log.Printf("更新榜单失败 - 提交ID: %s, 错误: %v", ev.SubmissionID, err)
+			continue
+		}
+		board.apply(submission)
+	}
+}
+
+// Invalidate 比赛设置、题目或报名变化后丢弃榜单，下次查询时重建
+func (s *ScoreboardService) Invalidate(contestID uint) {
+	s.mu.Lock()
+	delete(s.boards, contestID)
+	s.mu.Unlock()
+}
+
+// Board 获取比赛榜单，未加载时从数据库构建
+// 构建期间持有锁，事件消费者随后会用数据库中的最新状态覆盖，不会丢失构建期间的变化
+func (s *ScoreboardService) Board(contestID uint) (*Scoreboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if board, ok := s.boards[contestID]; ok {
+		return board, nil
+	}
+	board, err := s.load(contestID)
+	if err != nil {
+		return nil, err
+	}
+	s.boards[contestID] = board
+	return board, nil
+}
+
+// load 从数据库构建榜单
+func (s *ScoreboardService) load(contestID uint) (*Scoreboard, error) {
+	board := &Scoreboard{
+		index:    make(map[int]int),
+		users:    make(map[string]*scoreUser),
+		revealed: make(map[revealKey]bool),
+		cache:    make(map[bool]*Standings),
+	}
+	if err := s.DB.Where("id = ?", contestID).First(&board.contest).Error; err != nil {
+		return nil, fmt.Errorf("查询比赛失败: %w", err)
+	}
+
+	if err := s.DB.Model(&models.ContestProblem{}).
+		Select("contest_problems.label, contest_problems.question_id, question.question_number, question.title, question.subtasks").
+		Joins("JOIN question ON question.id = contest_problems.question_id").
+		Where("contest_problems.contest_id = ?", contestID).
+		Order("LENGTH(contest_problems.label) ASC, contest_problems.label ASC").
+		Scan(&board.problems).Error; err != nil {
+		return nil, fmt.Errorf("查询比赛题目失败: %w", err)
+	}
+	for i, p := range board.problems {
+		board.index[p.QuestionID] = i
+		if board.scored() {
+			board.problems[i].FullScore = (&models.Question{Subtasks: p.Subtasks}).FullScore()
+		}
+	}
+
+	var users []scoreUser
+	if err := s.DB.Model(&models.ContestRegistration{}).
+		Select("contest_registrations.user_id, `user`.username, `user`.nickname").
+		Joins("LEFT JOIN `user` ON `user`.uuid = contest_registrations.user_id").
+		Where("contest_registrations.contest_id = ?", contestID).
+		Scan(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询报名失败: %w", err)
+	}
+	for i := range users {
+		users[i].subs = make([][]scoreSub, len(board.problems))
+		board.users[users[i].UserID] = &users[i]
+	}
+
+	var reveals []models.ContestReveal
+	if err := s.DB.Where("contest_id = ?", contestID).Find(&reveals).Error; err != nil {
+		return nil, fmt.Errorf("查询滚榜记录失败: %w", err)
+	}
+	for _, r := range reveals {
+		board.revealed[revealKey{r.UserID, r.QuestionID}] = true
+	}
+
+	var submissions []models.Submission
+	if err := s.DB.Select("id", "user_id", "question_id", "judge_mode", "status", "verdict", "score", "subtask_scores", "virtual", "created_at").
+		Where("contest_id = ? AND judge_mode = ? AND submissions.virtual = ?", contestID, models.JudgeModeFull, false).
+		Order("created_at ASC").Find(&submissions).Error; err != nil {
+		return nil, fmt.Errorf("查询比赛提交失败: %w", err)
+	}
+	for _, sub := range submissions {
+		board.apply(sub)
+	}
+	return board, nil
+}
+
+// VirtualStandings 虚拟参赛榜单：原参赛选手截至相同相对时间（不封榜）的结果，
+// 加上虚拟选手的提交（提交时间按虚拟参赛开始时间换算到原比赛时间）
+func (s *ScoreboardService) VirtualStandings(contestID uint, vp *models.VirtualParticipation, now time.Time) (*Standings, error) {
+	board, err := s.Board(contestID)
+	if err != nil {
+		return nil, err
+	}
+	// 不读取 virtual 列，换算时间后按普通提交写入临时榜单
+	var submissions []models.Submission
+	if err := s.DB.Select("id", "user_id", "question_id", "judge_mode", "status", "verdict", "score", "subtask_scores", "created_at").
+		Where("contest_id = ? AND user_id = ? AND submissions.virtual = ? AND judge_mode = ?", contestID, vp.UserID, true, models.JudgeModeFull).
+		Order("created_at ASC").Find(&submissions).Error; err != nil {
+		return nil, fmt.Errorf("查询虚拟参赛提交失败: %w", err)
+	}
+	var user scoreUser
+	if err := s.DB.Table("`user`").Select("username, nickname").Where("uuid = ?", vp.UserID).Scan(&user).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	user.UserID = vp.UserID
+	user.virtual = true
+
+	elapsed := now.Sub(vp.StartTime)
+	if d := vp.EndTime.Sub(vp.StartTime); elapsed > d {
+		elapsed = d
+	}
+
+	board.mu.Lock()
+	v := &Scoreboard{
+		contest:  board.contest,
+		problems: board.problems,
+		index:    board.index,
+		users:    make(map[string]*scoreUser, len(board.users)+1),
+		revealed: make(map[revealKey]bool),
+		cache:    make(map[bool]*Standings),
+	}
+	cutoff := v.contest.StartTime.Add(elapsed)
+	for id, u := range board.users {
+		cu := &scoreUser{UserID: u.UserID, Username: u.Username, Nickname: u.Nickname, subs: make([][]scoreSub, len(u.subs))}
+		for i, subs := range u.subs {
+			for _, sub := range subs {
+				if sub.At.Before(cutoff) {
+					cu.subs[i] = append(cu.subs[i], sub)
+				}
+			}
+		}
+		v.users[id] = cu
+	}
+	board.mu.Unlock()
+
+	v.contest.FreezeMinutes = 0
+	user.subs = make([][]scoreSub, len(v.problems))
+	v.users[vp.UserID] = &user
+	for _, sub := range submissions {
+		sub.CreatedAt = v.contest.StartTime.Add(sub.CreatedAt.Sub(vp.StartTime))
+		v.apply(sub)
+	}
+	return v.Standings(false), nil
+}
+
+// RevealResult 滚榜公布一个格子的结果
+type RevealResult struct {
+	UserID    string    `json:"user_id"`
+	Label     string    `json:"label"`
+	Cell      ScoreCell `json:"cell"`
+	Rank      int       `json:"rank"`      // 公布后的排名
+	Remaining int       `json:"remaining"` // 仍未公布的格子数
+}
+
+// Reveal 滚榜：公布下一个格子；全部公布后解除封榜
+func (s *ScoreboardService) Reveal(contestID uint) (*RevealResult, error) {
+	board, err := s.Board(contestID)
+	if err != nil {
+		return nil, err
+	}
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	userID, questionID, ok := board.nextReveal()
+	if !ok {
+		return nil, ErrNothingToReveal
+	}
+	reveal := models.ContestReveal{ContestID: contestID, UserID: userID, QuestionID: questionID}
+	if err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reveal).Error; err != nil {
+		return nil, fmt.Errorf("保存滚榜记录失败: %w", err)
+	}
+	board.revealed[revealKey{userID, questionID}] = true
+	board.cache = make(map[bool]*Standings)
+
+	result := &RevealResult{UserID: userID, Label: board.problems[board.index[questionID]].Label}
+	st := board.standings(true)
+	for _, row := range st.Rows {
+		if row.UserID == userID {
+			result.Cell = row.Cells[board.index[questionID]]
+			result.Rank = row.Rank
+		}
+		for _, c := range row.Cells {
+			if c.Frozen > 0 {
+				result.Remaining++
+			}
+		}
+	}
+	if result.Remaining == 0 {
+		if err := s.setUnfrozen(board); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Unfreeze 直接解除封榜，公布全部结果
+func (s *ScoreboardService) Unfreeze(contestID uint) error {
+	board, err := s.Board(contestID)
+	if err != nil {
+		return err
+	}
+	board.mu.Lock()
+	defer board.mu.Unlock()
+	return s.setUnfrozen(board)
+}
+
+// setUnfrozen 标记比赛已解除封榜。调用方需持有 board.mu
+func (s *ScoreboardService) setUnfrozen(board *Scoreboard) error {
+	if err := s.DB.Model(&models.Contest{}).Where("id = ?", board.contest.ID).Update("unfrozen", true).Error; err != nil {
+		return fmt.Errorf("解除封榜失败: %w", err)
+	}
+	board.contest.Unfrozen = true
+	board.cache = make(map[bool]*Standings)
+	return nil
+}
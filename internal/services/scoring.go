@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/json"
+
+	"dachuang/internal/models"
+)
+
+// ScoreResults 根据子任务配置计算得分：子任务内的测试点全部通过才得分；
+// 未配置子任务时按通过测试点的比例计 100 分。results 需已写入 Subtask
+func ScoreResults(subtasks []models.Subtask, results []models.TestCaseResult) (int, []models.SubtaskScore) {
+	if len(subtasks) == 0 {
+		passed := 0
+		for _, r := range results {
+			if r.IsCorrect {
+				passed++
+			}
+		}
+		score := 0
+		if len(results) > 0 {
+			score = 100 * passed / len(results)
+		}
+		return score, []models.SubtaskScore{{ID: 0, Score: score, Full: 100}}
+	}
+
+	total := 0
+	scores := make([]models.SubtaskScore, 0, len(subtasks))
+	for _, st := range subtasks {
+		cases, passed := 0, 0
+		for _, r := range results {
+			if r.Subtask != st.ID {
+				continue
+			}
+			cases++
+			if r.IsCorrect {
+				passed++
+			}
+		}
+		s := models.SubtaskScore{ID: st.ID, Full: st.Score}
+		if cases > 0 && passed == cases {
+			s.Score = st.Score
+		}
+		total += s.Score
+		scores = append(scores, s)
+	}
+	return total, scores
+}
+
+// ParseSubtaskScores 解析 Submission.SubtaskScores，格式错误时返回空
+func ParseSubtaskScores(s string) []models.SubtaskScore {
+	var scores []models.SubtaskScore
+	if s == "" || json.Unmarshal([]byte(s), &scores) != nil {
+		return nil
+	}
+	return scores
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dachuang/internal/config"
+	"dachuang/internal/oss"
+)
+
+// SourceFile 多文件提交中的一个源文件，Path 为相对项目根目录的路径
+type SourceFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ErrSourceFiles 多文件提交不合法（路径、数量或大小超限）
+var ErrSourceFiles = errors.New("提交文件不合法")
+
+// SourceLimits 多文件提交的数量与大小上限
+type SourceLimits struct {
+	MaxFiles     int
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// SourceLimitsFromConfig 读取配置中的上限，未配置时使用默认值
+func SourceLimitsFromConfig(cfg config.MultiFileConfig) SourceLimits {
+	limits := SourceLimits{MaxFiles: 64, MaxFileSize: 256 << 10, MaxTotalSize: 1 << 20}
+	if cfg.MaxFiles > 0 {
+		limits.MaxFiles = cfg.MaxFiles
+	}
+	if cfg.MaxFileKB > 0 {
+		limits.MaxFileSize = int64(cfg.MaxFileKB) << 10
+	}
+	if cfg.MaxTotalKB > 0 {
+		limits.MaxTotalSize = int64(cfg.MaxTotalKB) << 10
+	}
+	return limits
+}
+
+// maxSourcePathDepth 源文件路径的最大层数
+const maxSourcePathDepth = 8
+
+// sourcePathSegment 路径中每一段只允许常规字符，且不能以 . 开头（排除 ..、隐藏文件）
+var sourcePathSegment = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._+-]{0,127}$`)
+
+// reservedSourcePaths 构建产物使用的文件名，不能出现在提交中
+var reservedSourcePaths = map[string]bool{"main": true, "main.jar": true}
+
+// sanitizeSourcePath 规范化并校验相对路径，拒绝绝对路径、越界路径和特殊字符
+func sanitizeSourcePath(p string) (string, error) {
+	if p == "" || strings.Contains(p, "\\") || strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%w: 非法路径 %q", ErrSourceFiles, p)
+	}
+	cleaned := path.Clean(p)
+	segments := strings.Split(cleaned, "/")
+	if len(segments) > maxSourcePathDepth {
+		return "", fmt.Errorf("%w: 路径层数过多 %q", ErrSourceFiles, p)
+	}
+	for _, seg := range segments {
+		if !sourcePathSegment.MatchString(seg) {
+			return "", fmt.Errorf("%w: 非法路径 %q", ErrSourceFiles, p)
+		}
+	}
+	if reservedSourcePaths[cleaned] {
+		return "", fmt.Errorf("%w: 文件名 %q 为构建产物保留", ErrSourceFiles, p)
+	}
+	return cleaned, nil
+}
+
+// SanitizeSourceFiles 校验文件数量、大小与路径，返回按路径排序的文件列表
+func SanitizeSourceFiles(files []SourceFile, limits SourceLimits) ([]SourceFile, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: 没有文件", ErrSourceFiles)
+	}
+	if len(files) > limits.MaxFiles {
+		return nil, fmt.Errorf("%w: 文件数 %d 超过上限 %d", ErrSourceFiles, len(files), limits.MaxFiles)
+	}
+
+	out := make([]SourceFile, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	dirs := make(map[string]bool)
+	var total int64
+	for _, f := range files {
+		p, err := sanitizeSourcePath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("%w: 重复的文件 %q", ErrSourceFiles, p)
+		}
+		seen[p] = true
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+		size := int64(len(f.Content))
+		if size > limits.MaxFileSize {
+			return nil, fmt.Errorf("%w: 文件 %q 超过大小上限", ErrSourceFiles, p)
+		}
+		total += size
+		if total > limits.MaxTotalSize {
+			return nil, fmt.Errorf("%w: 文件总大小超过上限", ErrSourceFiles)
+		}
+		out = append(out, SourceFile{Path: p, Content: f.Content})
+	}
+	for p := range seen {
+		if dirs[p] {
+			return nil, fmt.Errorf("%w: %q 同时作为文件和目录", ErrSourceFiles, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// ExtractArchive 解压 zip / tar / tar.gz 压缩包；只接受普通文件，读取时即按上限截断，
+// 所有文件位于同一顶层目录时去掉该目录
+func ExtractArchive(filename string, data []byte, limits SourceLimits) ([]SourceFile, error) {
+	name := strings.ToLower(filename)
+	var files []SourceFile
+	var err error
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		files, err = extractZip(data, limits)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, gzErr := gzip.NewReader(bytes.NewReader(data))
+		if gzErr != nil {
+			return nil, fmt.Errorf("%w: 无法解压 %v", ErrSourceFiles, gzErr)
+		}
+		files, err = extractTar(gz, limits)
+	case strings.HasSuffix(name, ".tar"):
+		files, err = extractTar(bytes.NewReader(data), limits)
+	default:
+		return nil, fmt.Errorf("%w: 仅支持 .zip / .tar / .tar.gz", ErrSourceFiles)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return SanitizeSourceFiles(stripCommonRoot(files), limits)
+}
+
+// archiveReader 按文件与总量上限读取压缩包中的文件
+type archiveReader struct {
+	limits SourceLimits
+	files  []SourceFile
+	total  int64
+}
+
+func (ar *archiveReader) add(name string, r io.Reader) error {
+	if len(ar.files) >= ar.limits.MaxFiles {
+		return fmt.Errorf("%w: 文件数超过上限 %d", ErrSourceFiles, ar.limits.MaxFiles)
+	}
+	b, err := io.ReadAll(io.LimitReader(r, ar.limits.MaxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("%w: 读取 %q 失败: %v", ErrSourceFiles, name, err)
+	}
+	if int64(len(b)) > ar.limits.MaxFileSize {
+		return fmt.Errorf("%w: 文件 %q 超过大小上限", ErrSourceFiles, name)
+	}
+	ar.total += int64(len(b))
+	if ar.total > ar.limits.MaxTotalSize {
+		return fmt.Errorf("%w: 文件总大小超过上限", ErrSourceFiles)
+	}
+	ar.files = append(ar.files, SourceFile{Path: name, Content: string(b)})
+	return nil
+}
+
+func extractZip(data []byte, limits SourceLimits) ([]SourceFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无法解压 %v", ErrSourceFiles, err)
+	}
+	ar := &archiveReader{limits: limits}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return nil, fmt.Errorf("%w: 不支持的文件类型 %q", ErrSourceFiles, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: 读取 %q 失败: %v", ErrSourceFiles, f.Name, err)
+		}
+		err = ar.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ar.files, nil
+}
+
+func extractTar(r io.Reader, limits SourceLimits) ([]SourceFile, error) {
+	tr := tar.NewReader(r)
+	ar := &archiveReader{limits: limits}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: 无法解压 %v", ErrSourceFiles, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+			if err := ar.add(hdr.Name, tr); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: 不支持的文件类型 %q", ErrSourceFiles, hdr.Name)
+		}
+	}
+	return ar.files, nil
+}
+
+// stripCommonRoot 所有文件都在同一个顶层目录下时去掉该目录（常见于直接压缩项目文件夹）
+func stripCommonRoot(files []SourceFile) []SourceFile {
+	if len(files) == 0 {
+		return files
+	}
+	root := ""
+	for _, f := range files {
+		p := strings.TrimPrefix(f.Path, "./")
+		i := strings.Index(p, "/")
+		if i <= 0 {
+			return files
+		}
+		if root == "" {
+			root = p[:i+1]
+		} else if !strings.HasPrefix(p, root) {
+			return files
+		}
+	}
+	out := make([]SourceFile, len(files))
+	for i, f := range files {
+		out[i] = SourceFile{Path: strings.TrimPrefix(strings.TrimPrefix(f.Path, "./"), root), Content: f.Content}
+	}
+	return out
+}
+
+// DetectProjectLanguage 根据文件扩展名识别项目语言
+func DetectProjectLanguage(files []SourceFile) string {
+	counts := make(map[string]int)
+	for _, f := range files {
+		switch strings.ToLower(path.Ext(f.Path)) {
+		case ".go":
+			counts["go"]++
+		case ".java":
+			counts["java"]++
+		case ".cpp", ".cc", ".cxx", ".hpp", ".h":
+			counts["cpp"]++
+		case ".py":
+			counts["python"]++
+		}
+		if path.Base(f.Path) == "go.mod" {
+			return "go"
+		}
+	}
+	best, bestCount := "", 0
+	for _, lang := range []string{"go", "cpp", "java", "python"} {
+		if counts[lang] > bestCount {
+			best, bestCount = lang, counts[lang]
+		}
+	}
+	return best
+}
+
+// projectRunArgs 多文件项目构建产物与运行命令
+func projectRunArgs(language string) (artifact string, args []string) {
+	switch language {
+	case "go", "cpp":
+		return "main", []string{"./main"}
+	case "java":
+		return "main.jar", []string{"java", "-cp", "main.jar", "Main"}
+	case "python":
+		return "", []string{"python3", "main.py"}
+	}
+	return "", nil
+}
+
+// writeSourceFiles 将项目文件写入目录
+func writeSourceFiles(dir string, files []SourceFile) error {
+	for _, f := range files {
+		target := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(f.Content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submissionFilesPrefix 提交文件在 OSS 中的目录
+func submissionFilesPrefix(submissionID string) string {
+	return "submissions/" + submissionID + "/"
+}
+
+// StoreSubmissionFiles 将提交的文件上传到 OSS 的 submissions/<id>/ 下，返回该前缀
+func StoreSubmissionFiles(ctx context.Context, ossClient *oss.OSS, bucket, submissionID string, files []SourceFile) (string, error) {
+	prefix := submissionFilesPrefix(submissionID)
+	for _, f := range files {
+		if _, err := ossClient.UploadFile(ctx, bucket, prefix+f.Path, strings.NewReader(f.Content), int64(len(f.Content)), "text/plain"); err != nil {
+			return "", fmt.Errorf("上传提交文件失败(%s): %w", f.Path, err)
+		}
+	}
+	return prefix, nil
+}
+
+// LoadSubmissionFiles 从 OSS 读取提交的全部文件
+func LoadSubmissionFiles(ctx context.Context, ossClient *oss.OSS, bucket, prefix string) ([]SourceFile, error) {
+	if ossClient == nil {
+		return nil, fmt.Errorf("OSS 未初始化，无法读取提交文件")
+	}
+	keys, err := ossClient.ListObjects(ctx, bucket, prefix, true)
+	if err != nil {
+		return nil, fmt.Errorf("列出提交文件失败: %w", err)
+	}
+	files := make([]SourceFile, 0, len(keys))
+	for _, key := range keys {
+		b, err := ossClient.GetObjectBytes(ctx, bucket, key)
+		if err != nil {
+			return nil, fmt.Errorf("读取提交文件失败(%s): %w", key, err)
+		}
+		files = append(files, SourceFile{Path: strings.TrimPrefix(key, prefix), Content: string(b)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// CheckProject 检查多文件提交能否评测：语言需支持多文件、已配置构建命令，python 需有入口 main.py
+func (js *JudgeService) CheckProject(language string, files []SourceFile) error {
+	artifact, args := projectRunArgs(language)
+	if args == nil {
+		return fmt.Errorf("%w: 不支持的语言 %q", ErrSourceFiles, language)
+	}
+	if artifact != "" && strings.TrimSpace(js.Config.MultiFile.Build[language]) == "" {
+		return fmt.Errorf("%w: 未配置 %s 的构建命令", ErrSourceFiles, language)
+	}
+	if language == "python" {
+		for _, f := range files {
+			if f.Path == "main.py" {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: python 项目需要入口文件 main.py", ErrSourceFiles)
+	}
+	return nil
+}
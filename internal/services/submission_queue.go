@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"dachuang/internal/models"
+)
+
+// 提交优先级，数值越小越先评测
+const (
+	PriorityContest  = 0 // 比赛提交
+	PriorityPractice = 1 // 日常练习（默认）
+	PriorityCustom   = 2 // 自测运行（样例评测）
+	PriorityRejudge  = 3 // 重新评测
+)
+
+// PriorityNames 优先级名称，下标即优先级
+var PriorityNames = []string{"contest", "practice", "custom", "rejudge"}
+
+// ErrNotQueued 提交不在队列中（已开始评测或不存在）
+var ErrNotQueued = errors.New("提交不在评测队列中")
+
+// ParsePriority 解析优先级名称
+func ParsePriority(name string) (int, bool) {
+	for i, n := range PriorityNames {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// QueueItem 队列中的一条提交
+type QueueItem struct {
+	Submission *models.Submission
+	Priority   int       // 入队或管理员调整后的优先级
+	EnqueuedAt time.Time // 入队时间，用于排队耗时统计
+	agingFrom  time.Time // 老化起算时间，入队时为 EnqueuedAt，提到队首时重置
+	seq        int64     // 同级内的先后顺序，越小越靠前
+}
+
+// QueueEntry 队列快照中的一项
+type QueueEntry struct {
+	SubmissionID      string    `json:"submission_id"`
+	UserID            string    `json:"user_id"`
+	QuestionID        int       `json:"question_id"`
+	Priority          string    `json:"priority"`
+	EffectivePriority string    `json:"effective_priority"` // 计入老化后的优先级
+	Position          int       `json:"position"`
+	EnqueuedAt        time.Time `json:"enqueued_at"`
+	WaitSeconds       int       `json:"wait_seconds"`
+}
+
+// SubmissionQueue 带优先级的评测队列
+//
+// 每等待 aging 时长优先级提升一级（不超过最高级），避免低优先级提交在高负载时被无限推迟；
+// 同一有效优先级内按入队顺序评测，管理员可将提交调整到所在级别的队首
+type SubmissionQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []*QueueItem
+	aging  time.Duration
+	seq    int64
+	minSeq int64
+}
+
+// NewSubmissionQueue 创建评测队列，aging <= 0 时不做老化
+func NewSubmissionQueue(aging time.Duration) *SubmissionQueue {
+	q := &SubmissionQueue{aging: aging}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// effective 计入老化后的优先级
+func (q *SubmissionQueue) effective(item *QueueItem, now time.Time) int {
+	p := item.Priority
+	if q.aging > 0 {
+		p -= int(now.Sub(item.agingFrom) / q.aging)
+	}
+	if p < PriorityContest {
+		p = PriorityContest
+	}
+	return p
+}
+
+// ordered 按评测顺序排列的队列副本，调用方需持有锁
+func (q *SubmissionQueue) ordered(now time.Time) []*QueueItem {
+	items := append([]*QueueItem(nil), q.items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, pj := q.effective(items[i], now), q.effective(items[j], now)
+		if pi != pj {
+			return pi < pj
+		}
+		return items[i].seq < items[j].seq
+	})
+	return items
+}
+
+// Push 加入队列，返回当前排队位置
+func (q *SubmissionQueue) Push(submission *models.Submission, priority int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	now := time.Now()
+	item := &QueueItem{Submission: submission, Priority: priority, EnqueuedAt: now, agingFrom: now, seq: q.seq}
+	q.items = append(q.items, item)
+	q.cond.Signal()
+	for i, it := range q.ordered(now) {
+		if it == item {
+			return i + 1
+		}
+	}
+	return len(q.items)
+}
+
+// Pop 取出下一个待评测的提交，队列为空时阻塞
+func (q *SubmissionQueue) Pop() *QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 {
+		q.cond.Wait()
+	}
+	next := q.ordered(time.Now())[0]
+	q.remove(next.Submission.ID)
+	return next
+}
+
+// remove 移除指定提交，调用方需持有锁
+func (q *SubmissionQueue) remove(submissionID string) *QueueItem {
+	for i, it := range q.items {
+		if it.Submission.ID == submissionID {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return it
+		}
+	}
+	return nil
+}
+
+// Remove 将提交移出队列（取消评测）
+func (q *SubmissionQueue) Remove(submissionID string) (*QueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if it := q.remove(submissionID); it != nil {
+		return it, nil
+	}
+	return nil, ErrNotQueued
+}
+
+// Move 调整提交的优先级（priority < 0 时保持不变）；toFront 为真时排到同级最前面
+func (q *SubmissionQueue) Move(submissionID string, priority int, toFront bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, it := range q.items {
+		if it.Submission.ID != submissionID {
+			continue
+		}
+		if priority >= 0 {
+			it.Priority = priority
+		}
+		if toFront {
+			// 老化从现在开始计算，避免被提前的提交因老化越级；入队时间不变，排队耗时仍从入队算起
+			q.minSeq--
+			it.seq = q.minSeq
+			it.agingFrom = time.Now()
+		}
+		return nil
+	}
+	return ErrNotQueued
+}
+
+// Len 队列长度
+func (q *SubmissionQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Position 提交当前的排队位置，不在队列中时返回 0
+func (q *SubmissionQueue) Position(submissionID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, it := range q.ordered(time.Now()) {
+		if it.Submission.ID == submissionID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Snapshot 按评测顺序返回队列快照
+func (q *SubmissionQueue) Snapshot() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	ordered := q.ordered(now)
+	entries := make([]QueueEntry, len(ordered))
+	for i, it := range ordered {
+		entries[i] = QueueEntry{
+			SubmissionID:      it.Submission.ID,
+			UserID:            it.Submission.UserID,
+			QuestionID:        it.Submission.QuestionID,
+			Priority:          PriorityNames[it.Priority],
+			EffectivePriority: PriorityNames[q.effective(it, now)],
+			Position:          i + 1,
+			EnqueuedAt:        it.EnqueuedAt,
+			WaitSeconds:       int(now.Sub(it.EnqueuedAt).Seconds()),
+		}
+	}
+	return entries
+}